@@ -0,0 +1,230 @@
+package gopdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+)
+
+/**
+使用数据构建的Table的汇总行.
+
+	SetAggregates  在表格的最后添加总计行, 按照列计算 sum, avg, min, max, count
+	SetGroupBy     分组的列的值变化的时候(数据需要按照分组的列排序), 在每一组的后面添加小计行, 使用相同的汇总
+
+sum, avg, min, max 只计算数值(格式参考 style.go), count 计算非空的值. 汇总的值默认使用列的格式, count 使用整数,
+列没有格式的时候 avg 保留两位小数, sum, min, max 的小数位数和数据当中最多的相同.
+汇总行使用 SetSummaryStyle 的样式(默认是粗体), 并且和上一行写在同一页, 跨页的逻辑和普通的行相同.
+**/
+
+const (
+	AGGREGATE_SUM   = 1 // 求和
+	AGGREGATE_AVG   = 2 // 平均值
+	AGGREGATE_MIN   = 3 // 最小值
+	AGGREGATE_MAX   = 4 // 最大值
+	AGGREGATE_COUNT = 5 // 非空的个数
+)
+
+// 列的汇总
+type Aggregate struct {
+	Col    int
+	Func   int
+	Format Formatter // 为空的时候使用列的格式
+}
+
+// 数据的一行, 已经格式化
+type builderRow struct {
+	cells   []string
+	summary bool // 汇总行
+}
+
+// 设置总计行, label 写在第一个没有汇总的列
+func (builder *TableBuilder) SetAggregates(label string, aggregates ...Aggregate) *TableBuilder {
+	for _, a := range aggregates {
+		if a.Col < 0 || a.Func < AGGREGATE_SUM || a.Func > AGGREGATE_COUNT {
+			panic("invalid aggregate")
+		}
+	}
+	builder.aggregates = aggregates
+	builder.totalLabel = label
+	builder.total = true
+	return builder
+}
+
+// 按照 col 列分组, 每一组的后面添加小计行. label 写在分组的列, 其中的 %s 替换为分组的值
+func (builder *TableBuilder) SetGroupBy(col int, label string) *TableBuilder {
+	if col < 0 {
+		panic("invalid column: " + strconv.Itoa(col))
+	}
+	builder.groupCol = col
+	builder.groupLabel = label
+	return builder
+}
+
+// 设置汇总行的样式
+func (builder *TableBuilder) SetSummaryStyle(style CellStyle) *TableBuilder {
+	checkCellStyle(style)
+	builder.summaryStyle = style
+	return builder
+}
+
+// 格式化数据, 插入小计行和总计行
+func (builder *TableBuilder) formatRows(values [][]interface{}, cols int, columns []TableColumn) []builderRow {
+	if builder.groupCol >= cols {
+		panic("invalid group column: " + strconv.Itoa(builder.groupCol))
+	}
+	for _, a := range builder.aggregates {
+		if a.Col >= cols {
+			panic("invalid aggregate column: " + strconv.Itoa(a.Col))
+		}
+	}
+
+	var (
+		rows  []builderRow
+		group [][]interface{} // 当前分组的数据
+		key   string          // 当前分组的值
+	)
+	for i, row := range values {
+		if builder.groupCol >= 0 {
+			value := builder.format(builder.groupCol, cellValue(row, builder.groupCol), columns)
+			if i > 0 && value != key {
+				rows = append(rows, builder.summaryRow(builder.subtotalLabel(key), builder.groupCol, group, cols, columns))
+				group = nil
+			}
+			key = value
+		}
+
+		cells := make([]string, cols)
+		for j := range cells {
+			cells[j] = builder.format(j, cellValue(row, j), columns)
+		}
+		rows = append(rows, builderRow{cells: cells})
+		group = append(group, row)
+	}
+
+	if builder.groupCol >= 0 && len(group) > 0 {
+		rows = append(rows, builder.summaryRow(builder.subtotalLabel(key), builder.groupCol, group, cols, columns))
+	}
+
+	if builder.total {
+		col := -1
+		for j := 0; j < cols && col == -1; j++ {
+			col = j
+			for _, a := range builder.aggregates {
+				if a.Col == j {
+					col = -1
+				}
+			}
+		}
+		rows = append(rows, builder.summaryRow(builder.totalLabel, col, values, cols, columns))
+	}
+
+	return rows
+}
+
+// 小计行的标签
+func (builder *TableBuilder) subtotalLabel(key string) string {
+	return strings.Replace(builder.groupLabel, "%s", key, -1)
+}
+
+// 列是否设置了格式
+func (builder *TableBuilder) hasFormat(col int, columns []TableColumn) bool {
+	if format, ok := builder.formatters[col]; ok && format != nil {
+		return true
+	}
+	return col < len(columns) && columns[col].Format != nil
+}
+
+// 汇总行, label 写在 col 列(col 为 -1 的时候不写)
+func (builder *TableBuilder) summaryRow(label string, col int, data [][]interface{}, cols int, columns []TableColumn) builderRow {
+	cells := make([]string, cols)
+	for _, a := range builder.aggregates {
+		value, ok := aggregate(a.Func, data, a.Col)
+		switch {
+		case !ok:
+		case a.Format != nil:
+			cells[a.Col] = a.Format(value)
+		case a.Func == AGGREGATE_COUNT:
+			cells[a.Col] = formatValue(value)
+		case a.Func == AGGREGATE_AVG && !builder.hasFormat(a.Col, columns):
+			cells[a.Col] = NumberFormatter(2)(value)
+		case !builder.hasFormat(a.Col, columns):
+			cells[a.Col] = strconv.FormatFloat(value.(float64), 'f', decimalPlaces(data, a.Col), 64)
+		default:
+			cells[a.Col] = builder.format(a.Col, value, columns)
+		}
+	}
+	if col >= 0 && cells[col] == "" {
+		cells[col] = label
+	}
+	return builderRow{cells: cells, summary: true}
+}
+
+// 计算汇总的值, 没有数值的时候返回 false(count 除外)
+func aggregate(fn int, data [][]interface{}, col int) (interface{}, bool) {
+	var (
+		count, n int
+		sum      float64
+		min, max = math.Inf(1), math.Inf(-1)
+	)
+	for _, row := range data {
+		value := cellValue(row, col)
+		if formatValue(value) == "" {
+			continue
+		}
+		count++
+
+		if v, ok := toFloat(value); ok {
+			n++
+			sum += v
+			min, max = math.Min(min, v), math.Max(max, v)
+		}
+	}
+
+	switch {
+	case fn == AGGREGATE_COUNT:
+		return count, true
+	case n == 0:
+		return nil, false
+	case fn == AGGREGATE_SUM:
+		return sum, true
+	case fn == AGGREGATE_AVG:
+		return sum / float64(n), true
+	case fn == AGGREGATE_MIN:
+		return min, true
+	}
+	return max, true
+}
+
+// 数值的最大小数位数, 避免浮点数的误差(10.1 + 20.2 = 30.299999999999997)
+func decimalPlaces(data [][]interface{}, col int) int {
+	places := 0
+	for _, row := range data {
+		value := cellValue(row, col)
+		if _, ok := toFloat(value); !ok {
+			continue
+		}
+
+		s := formatValue(value)
+		if i := strings.LastIndex(s, "."); i >= 0 {
+			n := 0
+			for _, c := range s[i+1:] {
+				if c < '0' || c > '9' {
+					break
+				}
+				n++
+			}
+			if n > places {
+				places = n
+			}
+		}
+	}
+	return places
+}
+
+func cellValue(row []interface{}, col int) interface{} {
+	if col < len(row) {
+		return row[col]
+	}
+	return nil
+}
@@ -0,0 +1,310 @@
+package gopdf
+
+import (
+	"math"
+
+	"github.com/tiechui1994/gopdf/util"
+)
+
+/**
+Table的边框.
+
+表格分为外边框(表格的四周, 以及分页处表格的顶部和底部)和内部的网格线, 单元格可以单独设置每一条边.
+相邻单元格的边框合并(border collapse)规则:
+	1. 两个单元格都没有设置(Style 为 0), 使用表格的外边框或者网格线.
+	2. 只有一个单元格设置, 使用该设置. BORDER_NONE 可以去掉一条边.
+	3. 两个单元格都设置了, 都是 BORDER_NONE 的时候没有边框; 否则忽略 BORDER_NONE, 宽度大的优先,
+	   宽度相同的时候按照 double, solid, dashed, dotted 的顺序, 仍然相同的时候上(左)侧的单元格优先.
+跨页拆分的单元格, 拆分处的边使用表格的外边框.
+**/
+
+const (
+	BORDER_SOLID  = 1 // 实线
+	BORDER_DASHED = 2 // 虚线
+	BORDER_DOTTED = 3 // 点线
+	BORDER_DOUBLE = 4 // 双线
+	BORDER_NONE   = 5 // 无边框
+
+	BORDER_WIDTH = 0.1 // 默认的线宽
+)
+
+const (
+	edgeLeft = iota
+	edgeTop
+	edgeRight
+	edgeBottom
+)
+
+// 边框线条, Style 为 0 表示没有设置
+type Border struct {
+	Style int
+	Color string  // 线条颜色, 默认是黑色
+	Width float64 // 线条宽度, 默认是 BORDER_WIDTH
+}
+
+func checkBorder(border Border) {
+	if border.Style < 0 || border.Style > BORDER_NONE {
+		panic("invalid border style")
+	}
+	if border.Width < 0 {
+		panic("invalid border width")
+	}
+	if border.Color != "" {
+		util.CheckColor(border.Color)
+	}
+}
+
+// 设置表格的外边框
+func (table *Table) SetOuterBorder(border Border) {
+	checkBorder(border)
+	if border.Style == 0 {
+		border.Style = BORDER_SOLID
+	}
+	table.outerBorder = border
+}
+
+// 设置表格内部的网格线
+func (table *Table) SetInnerBorder(border Border) {
+	checkBorder(border)
+	if border.Style == 0 {
+		border.Style = BORDER_SOLID
+	}
+	table.innerBorder = border
+}
+
+// 设置单元格四条边的边框
+func (cell *TableCell) SetBorder(border Border) *TableCell {
+	return cell.SetBorders(border, border, border, border)
+}
+
+// 设置单元格左, 上, 右, 下的边框, Style 为 0 的边使用表格的设置
+func (cell *TableCell) SetBorders(left, top, right, bottom Border) *TableCell {
+	for _, border := range []Border{left, top, right, bottom} {
+		checkBorder(border)
+	}
+	cell.borders = [4]Border{left, top, right, bottom}
+	return cell
+}
+
+func (border Border) width() float64 {
+	if border.Width == 0 {
+		return BORDER_WIDTH
+	}
+	return border.Width
+}
+
+// 宽度相同的时候边框的优先级
+func (border Border) priority() int {
+	switch border.Style {
+	case BORDER_DOUBLE:
+		return 4
+	case BORDER_SOLID:
+		return 3
+	case BORDER_DASHED:
+		return 2
+	case BORDER_DOTTED:
+		return 1
+	}
+	return 0
+}
+
+// 合并两个单元格之间的边框, a 是上(左)侧的边, b 是下(右)侧的边
+func (table *Table) collapseBorder(a, b Border, outer bool) Border {
+	switch {
+	case a.Style == 0 && b.Style == 0:
+		if outer {
+			return table.outerBorder
+		}
+		return table.innerBorder
+	case b.Style == 0 || b.Style == BORDER_NONE && a.Style != 0:
+		return a
+	case a.Style == 0 || a.Style == BORDER_NONE:
+		return b
+	}
+
+	if b.width() > a.width() || b.width() == a.width() && b.priority() > a.priority() {
+		return b
+	}
+	return a
+}
+
+// 覆盖 (row, col) 的单元格
+func (table *Table) cellAt(row, col int) *TableCell {
+	cell := table.cells[row][col]
+	if cell.rowspan < 1 {
+		return table.cells[-cell.rowspan][-cell.colspan]
+	}
+	return cell
+}
+
+// 单元格的边框. 每个片段画上边框和左边框, 最右侧的片段画右边框, 最下面的片段画下边框.
+// 相邻的单元格不同的时候, 同一条边按照列(行)分段合并
+func (table *Table) drawLines(layout tableLayout, fragments []tableFragment) {
+	var (
+		pen   = &borderPen{table: table}
+		none  Border
+		first = table.headerRows // 表格主体的第一行
+	)
+
+	for _, fragment := range fragments {
+		var (
+			cell        = fragment.cell
+			top, last   = cell.row, table.lastRow(cell)
+			x1, x2      = table.getColX(cell.col), table.getColX(cell.col + cell.colspan)
+			continued   = top < layout.start || layout.continued && top == layout.start
+			unfinished  = last > layout.end-1 || layout.split && last >= layout.end-1
+			atBottom    = math.Abs(fragment.bottom-layout.bottom) < 0.01
+			rows        = [2]int{top, last}
+			edge        []Border
+			left, right []Border
+		)
+		if rows[0] < layout.start {
+			rows[0] = layout.start
+		}
+		if rows[1] > layout.end-1 {
+			rows[1] = layout.end - 1
+		}
+
+		// 上边框
+		edge = edge[:0]
+		for col := cell.col; col < cell.col+cell.colspan; col++ {
+			switch {
+			case continued:
+				edge = append(edge, table.outerBorder)
+			case top == first:
+				edge = append(edge, table.collapseBorder(none, cell.borders[edgeTop], true))
+			default:
+				above := table.cellAt(top-1, col)
+				edge = append(edge, table.collapseBorder(above.borders[edgeBottom], cell.borders[edgeTop], top == layout.start))
+			}
+		}
+		pen.horizontal(edge, cell.col, fragment.top)
+
+		// 下边框
+		if atBottom {
+			edge = edge[:0]
+			for col := cell.col; col < cell.col+cell.colspan; col++ {
+				switch {
+				case unfinished:
+					edge = append(edge, table.outerBorder)
+				case last == table.end-1:
+					edge = append(edge, table.collapseBorder(cell.borders[edgeBottom], none, true))
+				default:
+					below := table.cellAt(last+1, col)
+					edge = append(edge, table.collapseBorder(cell.borders[edgeBottom], below.borders[edgeTop], true))
+				}
+			}
+			pen.horizontal(edge, cell.col, fragment.bottom)
+		}
+
+		// 左边框和右边框
+		for row := rows[0]; row <= rows[1]; row++ {
+			if cell.col == 0 {
+				left = append(left, table.collapseBorder(none, cell.borders[edgeLeft], true))
+			} else {
+				neighbor := table.cellAt(row, cell.col-1)
+				left = append(left, table.collapseBorder(neighbor.borders[edgeRight], cell.borders[edgeLeft], false))
+			}
+			right = append(right, table.collapseBorder(cell.borders[edgeRight], none, true))
+		}
+		pen.vertical(left, layout, rows[0], x1, fragment.top, fragment.bottom)
+		if cell.col+cell.colspan == table.cols {
+			pen.vertical(right, layout, rows[0], x2, fragment.top, fragment.bottom)
+		}
+	}
+
+	pen.reset()
+}
+
+// 画边框, 只有线型, 宽度, 颜色变化的时候才重新设置
+type borderPen struct {
+	table   *Table
+	current Border
+	colored bool
+}
+
+// 水平的边, borders[i] 是第 col+i 列的边框
+func (pen *borderPen) horizontal(borders []Border, col int, y float64) {
+	for i := 0; i < len(borders); {
+		j := i + 1
+		for j < len(borders) && borders[j] == borders[i] {
+			j++
+		}
+		pen.line(borders[i], pen.table.getColX(col+i), y, pen.table.getColX(col+j), y)
+		i = j
+	}
+}
+
+// 垂直的边, borders[i] 是第 row+i 行的边框, 第一行从 top 开始, 最后一行到 bottom 结束
+func (pen *borderPen) vertical(borders []Border, layout tableLayout, row int, x, top, bottom float64) {
+	for i := 0; i < len(borders); {
+		j := i + 1
+		for j < len(borders) && borders[j] == borders[i] {
+			j++
+		}
+
+		y1, y2 := top, bottom
+		if i > 0 {
+			y1 = layout.tops[row+i-layout.start]
+		}
+		if j < len(borders) {
+			y2 = layout.tops[row+j-layout.start]
+		}
+		pen.line(borders[i], x, y1, x, y2)
+		i = j
+	}
+}
+
+func (pen *borderPen) line(border Border, x1, y1, x2, y2 float64) {
+	if border.Style == BORDER_NONE || border.Style == 0 {
+		return
+	}
+
+	var (
+		pdf   = pen.table.pdf
+		width = border.width()
+	)
+	if border.Style == BORDER_DOUBLE {
+		width = width / 3
+	}
+
+	if pen.current.Style != border.Style || pen.current.width() != border.width() {
+		switch border.Style {
+		case BORDER_DASHED:
+			pdf.LineType("dashed", width)
+		case BORDER_DOTTED:
+			pdf.LineType("dotted", width)
+		default:
+			pdf.LineType("straight", width)
+		}
+	}
+	if pen.current.Style == 0 || pen.current.Color != border.Color {
+		color := border.Color
+		if color == "" {
+			color = "0,0,0"
+		}
+		pdf.LineColor(util.GetColorRGB(color))
+		pen.colored = true
+	}
+	pen.current = border
+
+	// 双线: 两条线的宽度和间距都是 Width 的 1/3
+	offsets := []float64{0}
+	if border.Style == BORDER_DOUBLE {
+		offsets = []float64{-width, width}
+	}
+	for _, offset := range offsets {
+		if y1 == y2 {
+			pdf.LineH(x1, y1+offset, x2)
+		} else {
+			pdf.LineV(x1+offset, y1, y2)
+		}
+	}
+}
+
+// 恢复默认的线条颜色
+func (pen *borderPen) reset() {
+	if pen.colored {
+		pen.table.pdf.LineDefaultColor()
+	}
+}
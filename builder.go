@@ -0,0 +1,543 @@
+package gopdf
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tiechui1994/gopdf/core"
+	"github.com/tiechui1994/gopdf/util"
+)
+
+/**
+使用数据构建Table.
+
+每一个单元格都是 TextCell. 列的设置(TableColumn)包括表头, 列宽(格式参考 column.go), 对齐方式和格式化函数.
+存在表头的时候, 表头是表格的第一行, 并且设置为 SetHeaderRows(1), 跨页的时候重复写入.
+没有任何列或者某一行的单元格多于列的时候, FromCSV 和 FromRows 返回错误, FromStrings 和 FromStructs panic.
+
+结构体字段的 tag 格式, 使用分号分隔:
+	`pdf:"header=名称;width=20%;align=right;format=number:2"`
+	`pdf:"-"` 忽略该字段, 没有 header 的时候使用字段名称
+
+format 的格式:
+	"number:2"      千分位, 2 位小数(默认 0 位)
+	"percent:1"     百分比, 0.125 => 12.5%
+	"currency:$"    货币, 千分位, 2 位小数
+	"date:layout"   时间, 使用 time.Time 的 layout, 默认是 "2006-01-02"
+**/
+
+const (
+	ALIGN_LEFT    = "left"
+	ALIGN_CENTER  = "center"
+	ALIGN_RIGHT   = "right"
+	ALIGN_JUSTIFY = "justify"
+)
+
+// 格式化单元格的值
+type Formatter func(value interface{}) string
+
+// 列的设置
+type TableColumn struct {
+	Header string    // 表头, 所有列的表头都为空的时候没有表头
+	Width  string    // 列宽, 为空的时候是 "*"
+	Align  string    // 对齐方式, 默认居左
+	Format Formatter // 格式化, 为空的时候使用默认的格式
+}
+
+// 使用数据构建表格
+type TableBuilder struct {
+	pdf               *core.Report
+	width, lineHeight float64
+	font              core.Font
+	padding           core.Scope
+
+	columns    []TableColumn
+	formatters map[int]Formatter
+	headerRow  bool // 数据的第一行是表头
+
+	aggregates   []Aggregate // 汇总, 参考 aggregate.go
+	totalLabel   string      // 总计行的标签
+	total        bool        // 表格的最后添加总计行
+	groupCol     int         // 分组的列, -1 表示不分组
+	groupLabel   string      // 小计行的标签
+	summaryStyle CellStyle   // 汇总行的样式
+}
+
+func NewTableBuilder(width, lineHeight float64, font core.Font, pdf *core.Report) *TableBuilder {
+	return &TableBuilder{
+		pdf:        pdf,
+		width:      width,
+		lineHeight: lineHeight,
+		font:       font,
+		formatters: make(map[int]Formatter),
+
+		groupCol:     -1,
+		summaryStyle: CellStyle{FontStyle: "B"},
+	}
+}
+
+// 设置列, 结构体的列使用 tag 设置
+func (builder *TableBuilder) SetColumns(columns ...TableColumn) *TableBuilder {
+	for _, c := range columns {
+		checkAlign(c.Align)
+		if c.Width != "" {
+			parseColumn(c.Width)
+		}
+	}
+	builder.columns = columns
+	return builder
+}
+
+// 设置 col 列的格式化函数, 优先于列设置(或者 tag)的格式
+func (builder *TableBuilder) SetFormatter(col int, format Formatter) *TableBuilder {
+	if col < 0 {
+		panic("invalid column: " + strconv.Itoa(col))
+	}
+	builder.formatters[col] = format
+	return builder
+}
+
+// 单元格的内边距
+func (builder *TableBuilder) SetPadding(padding core.Scope) *TableBuilder {
+	padding.ReplaceBorder()
+	builder.padding = padding
+	return builder
+}
+
+// 数据(字符串, CSV)的第一行作为表头
+func (builder *TableBuilder) FirstRowHeader() *TableBuilder {
+	builder.headerRow = true
+	return builder
+}
+
+// 使用字符串构建表格
+func (builder *TableBuilder) FromStrings(rows [][]string) *Table {
+	table, err := builder.fromStrings(rows)
+	if err != nil {
+		panic(err.Error())
+	}
+	return table
+}
+
+func (builder *TableBuilder) fromStrings(rows [][]string) (*Table, error) {
+	values := make([][]interface{}, len(rows))
+	for i, row := range rows {
+		values[i] = make([]interface{}, len(row))
+		for j := range row {
+			values[i][j] = row[j]
+		}
+	}
+	return builder.build(builder.columns, values, builder.headerRow)
+}
+
+// 使用结构体的切片(元素可以是指针)构建表格, 列使用结构体字段的 tag 设置
+func (builder *TableBuilder) FromStructs(slice interface{}) *Table {
+	v := reflect.ValueOf(slice)
+	if v.Kind() != reflect.Slice && v.Kind() != reflect.Array {
+		panic("the data must be a slice of struct")
+	}
+
+	t := v.Type().Elem()
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		panic("the data must be a slice of struct")
+	}
+
+	var (
+		columns []TableColumn
+		fields  []int
+	)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("pdf")
+		if field.PkgPath != "" || tag == "-" {
+			continue
+		}
+		columns = append(columns, parseColumnTag(field.Name, tag))
+		fields = append(fields, i)
+	}
+
+	values := make([][]interface{}, v.Len())
+	for i := range values {
+		item := v.Index(i)
+		if item.Kind() == reflect.Ptr {
+			if item.IsNil() {
+				values[i] = make([]interface{}, len(fields))
+				continue
+			}
+			item = item.Elem()
+		}
+		for _, k := range fields {
+			values[i] = append(values[i], item.Field(k).Interface())
+		}
+	}
+
+	table, err := builder.build(columns, values, false)
+	if err != nil {
+		panic(err.Error())
+	}
+	return table
+}
+
+// 使用 CSV 构建表格, comma 是分隔符(TSV 使用 '\t')
+func (builder *TableBuilder) FromCSV(reader io.Reader, comma rune) (*Table, error) {
+	r := csv.NewReader(reader)
+	r.Comma = comma
+	r.FieldsPerRecord = -1
+	r.LazyQuotes = true
+
+	records, err := r.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	return builder.fromStrings(records)
+}
+
+// 使用数据库的查询结果构建表格, 没有设置表头的时候使用查询的列名. rows 会被关闭
+func (builder *TableBuilder) FromRows(rows *sql.Rows) (*Table, error) {
+	defer rows.Close()
+
+	names, err := rows.Columns()
+	if err != nil {
+		return nil, err
+	}
+
+	columns := make([]TableColumn, len(names))
+	copy(columns, builder.columns)
+	for i := range columns {
+		if columns[i].Header == "" {
+			columns[i].Header = names[i]
+		}
+	}
+
+	var values [][]interface{}
+	for rows.Next() {
+		row := make([]interface{}, len(names))
+		dest := make([]interface{}, len(names))
+		for i := range row {
+			dest[i] = &row[i]
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		values = append(values, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return builder.build(columns, values, false)
+}
+
+func (builder *TableBuilder) build(columns []TableColumn, values [][]interface{}, headerRow bool) (*Table, error) {
+	cols := len(columns)
+	for _, row := range values {
+		if len(columns) > 0 && len(row) > len(columns) {
+			return nil, errors.New("the row has more cells than columns")
+		}
+		if len(row) > cols {
+			cols = len(row)
+		}
+	}
+	if cols == 0 {
+		return nil, errors.New("there are no columns")
+	}
+
+	var (
+		header  []string
+		widths  = make([]string, cols)
+		resized bool
+	)
+	for i := range widths {
+		widths[i] = "*"
+		if i < len(columns) && columns[i].Width != "" {
+			widths[i], resized = columns[i].Width, true
+		}
+	}
+	for _, c := range columns {
+		if c.Header != "" {
+			header = make([]string, cols)
+			break
+		}
+	}
+	for i := range header {
+		if i < len(columns) {
+			header[i] = columns[i].Header
+		}
+	}
+	if headerRow && len(values) > 0 {
+		header = make([]string, cols)
+		for i, value := range values[0] {
+			header[i] = fmt.Sprint(value)
+		}
+		values = values[1:]
+	}
+
+	data := builder.formatRows(values, cols, columns)
+	rows := len(data)
+	if header != nil {
+		rows++
+	}
+
+	table := NewTable(cols, rows, builder.width, builder.lineHeight, builder.pdf)
+	if resized {
+		table.SetColumnWidths(widths...)
+	}
+	if header != nil {
+		table.SetHeaderRows(1)
+		for j, s := range header {
+			builder.newCell(table, j, s, columns)
+		}
+	}
+
+	// 汇总行和上一行写在同一页
+	for i, row := range data {
+		for j, s := range row.cells {
+			builder.newCell(table, j, s, columns)
+		}
+		if row.summary {
+			r := i + table.headerRows
+			table.SetRowStyle(r, builder.summaryStyle)
+			if r > table.headerRows {
+				table.SetKeepWithNext(r - 1)
+			}
+		}
+	}
+
+	return table, nil
+}
+
+func (builder *TableBuilder) newCell(table *Table, col int, content string, columns []TableColumn) {
+	text := NewTextCell(table.colwidths[col]*table.width, builder.lineHeight, 0, builder.pdf)
+	text.SetBorder(builder.padding)
+	text.SetFont(builder.font)
+	if col < len(columns) {
+		switch columns[col].Align {
+		case ALIGN_CENTER:
+			text.HorizontalCentered()
+		case ALIGN_RIGHT:
+			text.RightAlign()
+		case ALIGN_JUSTIFY:
+			text.Justify()
+		}
+	}
+	text.SetContent(content)
+	table.NewCell().SetElement(text)
+}
+
+func (builder *TableBuilder) format(col int, value interface{}, columns []TableColumn) string {
+	if format, ok := builder.formatters[col]; ok && format != nil {
+		return format(value)
+	}
+	if col < len(columns) && columns[col].Format != nil {
+		return columns[col].Format(value)
+	}
+	return formatValue(value)
+}
+
+func checkAlign(align string) {
+	switch align {
+	case "", ALIGN_LEFT, ALIGN_CENTER, ALIGN_RIGHT, ALIGN_JUSTIFY:
+	default:
+		panic("invalid align: " + align)
+	}
+}
+
+// 解析结构体字段的 tag
+func parseColumnTag(name, tag string) TableColumn {
+	column := TableColumn{Header: name}
+	for _, item := range strings.Split(tag, ";") {
+		if strings.TrimSpace(item) == "" {
+			continue
+		}
+		kv := strings.SplitN(item, "=", 2)
+		if len(kv) != 2 {
+			panic("invalid tag: " + tag)
+		}
+
+		key, value := strings.TrimSpace(kv[0]), strings.TrimSpace(kv[1])
+		switch key {
+		case "header":
+			column.Header = value
+		case "width":
+			parseColumn(value)
+			column.Width = value
+		case "align":
+			checkAlign(value)
+			column.Align = value
+		case "format":
+			column.Format = ParseFormatter(value)
+		default:
+			panic("invalid tag: " + tag)
+		}
+	}
+	return column
+}
+
+// 解析格式, 格式参考 builder.go
+func ParseFormatter(spec string) Formatter {
+	var (
+		kv        = strings.SplitN(spec, ":", 2)
+		name, arg = kv[0], ""
+		digits    = func(def int) int {
+			if arg == "" {
+				return def
+			}
+			n, err := strconv.Atoi(arg)
+			if err != nil || n < 0 {
+				panic("invalid format: " + spec)
+			}
+			return n
+		}
+	)
+	if len(kv) == 2 {
+		arg = kv[1]
+	}
+
+	switch name {
+	case "number":
+		return NumberFormatter(digits(0))
+	case "percent":
+		return PercentFormatter(digits(0))
+	case "currency":
+		return CurrencyFormatter(arg, 2)
+	case "date":
+		if arg == "" {
+			arg = "2006-01-02"
+		}
+		return DateFormatter(arg)
+	}
+	panic("invalid format: " + spec)
+}
+
+// 数字, 千分位, decimals 位小数. 不是数字的值使用默认的格式
+func NumberFormatter(decimals int) Formatter {
+	return func(value interface{}) string {
+		v, ok := toFloat(value)
+		if !ok {
+			return formatValue(value)
+		}
+		return formatNumber(v, decimals)
+	}
+}
+
+// 百分比, 0.125 => 12.5%
+func PercentFormatter(decimals int) Formatter {
+	return func(value interface{}) string {
+		v, ok := toFloat(value)
+		if !ok {
+			return formatValue(value)
+		}
+		return formatNumber(v*100, decimals) + "%"
+	}
+}
+
+// 货币, 负数的符号在货币符号之前: -$1,234.50
+func CurrencyFormatter(symbol string, decimals int) Formatter {
+	return func(value interface{}) string {
+		v, ok := toFloat(value)
+		if !ok {
+			return formatValue(value)
+		}
+		s := formatNumber(v, decimals)
+		if strings.HasPrefix(s, "-") {
+			return "-" + symbol + s[1:]
+		}
+		return symbol + s
+	}
+}
+
+// 时间, 使用 time.Time 的 layout. 字符串按照 RFC3339 或者 "2006-01-02" 解析
+func DateFormatter(layout string) Formatter {
+	return func(value interface{}) string {
+		switch v := value.(type) {
+		case time.Time:
+			return v.Format(layout)
+		case *time.Time:
+			if v != nil {
+				return v.Format(layout)
+			}
+		case string:
+			for _, l := range []string{time.RFC3339, "2006-01-02 15:04:05", "2006-01-02"} {
+				if t, err := time.Parse(l, v); err == nil {
+					return t.Format(layout)
+				}
+			}
+		}
+		return formatValue(value)
+	}
+}
+
+func formatNumber(v float64, decimals int) string {
+	s := strconv.FormatFloat(math.Abs(v), 'f', decimals, 64)
+	s = util.AddComma(s)
+	if v < 0 && strings.Trim(s, "0.,") != "" {
+		s = "-" + s
+	}
+	return s
+}
+
+// 默认的格式
+func formatValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return ""
+	case string:
+		return v
+	case []byte:
+		return string(v)
+	case float32:
+		return strconv.FormatFloat(float64(v), 'f', -1, 32)
+	case float64:
+		return strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return v.Format("2006-01-02 15:04:05")
+	case fmt.Stringer:
+		return v.String()
+	}
+
+	rv := reflect.ValueOf(value)
+	if rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return ""
+		}
+		return formatValue(rv.Elem().Interface())
+	}
+	return fmt.Sprint(value)
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case string:
+		return parseNumber(v)
+	case []byte:
+		return parseNumber(string(v))
+	case nil:
+		return 0, false
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(rv.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true
+	case reflect.Ptr:
+		if !rv.IsNil() {
+			return toFloat(rv.Elem().Interface())
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,328 @@
+package gopdf
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+// 表格每一行的内容
+func tableContents(table *Table) (rows [][]string) {
+	for i := 0; i < table.rows; i++ {
+		var row []string
+		for j := 0; j < table.cols; j++ {
+			row = append(row, table.cells[i][j].element.(*TextCell).text)
+		}
+		rows = append(rows, row)
+	}
+	return rows
+}
+
+func checkContents(t *testing.T, table *Table, expect [][]string) {
+	got := tableContents(table)
+	if len(got) != len(expect) {
+		t.Fatalf("expect %v rows, got %v", len(expect), got)
+	}
+	for i := range expect {
+		if strings.Join(got[i], "|") != strings.Join(expect[i], "|") {
+			t.Errorf("row %v: expect %q, got %q", i, expect[i], got[i])
+		}
+	}
+}
+
+func TestBuilderStrings(t *testing.T) {
+	var (
+		font  = core.Font{Family: TABLE_MD, Size: 10}
+		table *Table
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table = NewTableBuilder(300, 20, font, report).
+			SetColumns(TableColumn{Header: "item"}, TableColumn{Header: "price", Width: "80", Align: ALIGN_RIGHT}).
+			SetFormatter(1, CurrencyFormatter("$", 2)).
+			FromStrings([][]string{{"apple", "1234.5"}, {"pear", "-3"}, {"none"}})
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	checkContents(t, table, [][]string{{"item", "price"}, {"apple", "$1,234.50"}, {"pear", "-$3.00"}, {"none", ""}})
+	if table.headerRows != 1 || table.colwidths[1]*table.width != 80 {
+		t.Errorf("expect 1 header row and 80pt price column, got %v %v", table.headerRows, table.colwidths[1]*table.width)
+	}
+	if !table.cells[1][1].element.(*TextCell).rightAlign {
+		t.Errorf("expect right aligned price")
+	}
+}
+
+type builderItem struct {
+	Name    string    `pdf:"header=Name;width=*"`
+	Price   float64   `pdf:"header=Price;align=right;format=number:2"`
+	Rate    float64   `pdf:"format=percent:1"`
+	Date    time.Time `pdf:"header=Date;format=date:2006/01/02"`
+	Comment string    `pdf:"-"`
+	hidden  int
+}
+
+func TestBuilderStructs(t *testing.T) {
+	var (
+		font  = core.Font{Family: TABLE_MD, Size: 10}
+		date  = time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC)
+		table *Table
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table = NewTableBuilder(300, 20, font, report).FromStructs([]*builderItem{
+			{Name: "a", Price: 12345.678, Rate: 0.125, Date: date, Comment: "x"},
+			nil,
+		})
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	checkContents(t, table, [][]string{
+		{"Name", "Price", "Rate", "Date"},
+		{"a", "12,345.68", "12.5%", "2024/03/05"},
+		{"", "", "", ""},
+	})
+}
+
+func TestBuilderCSV(t *testing.T) {
+	var (
+		font  = core.Font{Family: TABLE_MD, Size: 10}
+		table *Table
+		err   error
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table, err = NewTableBuilder(300, 20, font, report).FirstRowHeader().
+			FromCSV(strings.NewReader("name\tcount\n\"a\tb\"\t1000\nc\n"), '\t')
+	})
+	r.GetBytesPdf()
+
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkContents(t, table, [][]string{{"name", "count"}, {"a\tb", "1000"}, {"c", ""}})
+}
+
+func TestBuilderCSVEmpty(t *testing.T) {
+	TableReport(func(report *core.Report) {
+		builder := NewTableBuilder(300, 20, core.Font{Family: TABLE_MD, Size: 10}, report)
+		if _, err := builder.FromCSV(strings.NewReader(""), ','); err == nil {
+			t.Errorf("expect error for csv without columns")
+		}
+		if _, err := builder.SetColumns(TableColumn{Header: "a"}).FromCSV(strings.NewReader("1,2\n"), ','); err == nil {
+			t.Errorf("expect error for row with more cells than columns")
+		}
+	}).GetBytesPdf()
+}
+
+// 测试使用的数据库驱动, 查询的结果是固定的
+type builderDriver struct{}
+type builderConn struct{}
+type builderRows struct{ next int }
+
+func (builderDriver) Open(name string) (driver.Conn, error) { return builderConn{}, nil }
+
+func (builderConn) Prepare(query string) (driver.Stmt, error) { return builderConn{}, nil }
+func (builderConn) Close() error                              { return nil }
+func (builderConn) Begin() (driver.Tx, error)                 { return nil, driver.ErrSkip }
+func (builderConn) NumInput() int                             { return 0 }
+func (builderConn) Exec(args []driver.Value) (driver.Result, error) {
+	return nil, driver.ErrSkip
+}
+func (builderConn) Query(args []driver.Value) (driver.Rows, error) { return &builderRows{}, nil }
+
+func (*builderRows) Columns() []string { return []string{"id", "amount", "note"} }
+func (*builderRows) Close() error      { return nil }
+func (rows *builderRows) Next(dest []driver.Value) error {
+	data := [][]driver.Value{{int64(1), 2.5, []byte("ok")}, {int64(2), nil, "none"}}
+	if rows.next >= len(data) {
+		return io.EOF
+	}
+	copy(dest, data[rows.next])
+	rows.next++
+	return nil
+}
+
+func TestBuilderRows(t *testing.T) {
+	sql.Register("gopdf-builder", builderDriver{})
+	db, err := sql.Open("gopdf-builder", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	var table *Table
+	r := TableReport(func(report *core.Report) {
+		rows, err := db.Query("select")
+		if err != nil {
+			t.Fatal(err)
+		}
+		table, err = NewTableBuilder(300, 20, core.Font{Family: TABLE_MD, Size: 10}, report).
+			SetColumns(TableColumn{Header: "ID"}).
+			SetFormatter(1, NumberFormatter(1)).
+			FromRows(rows)
+		if err != nil {
+			t.Fatal(err)
+		}
+	})
+	r.GetBytesPdf()
+
+	checkContents(t, table, [][]string{{"ID", "amount", "note"}, {"1", "2.5", "ok"}, {"2", "", "none"}})
+}
+
+func TestBuilderFormatters(t *testing.T) {
+	cases := []struct {
+		format Formatter
+		value  interface{}
+		expect string
+	}{
+		{NumberFormatter(0), 1234567, "1,234,567"},
+		{NumberFormatter(2), "-0.001", "0.00"},
+		{NumberFormatter(1), "n/a", "n/a"},
+		{PercentFormatter(0), 0.5, "50%"},
+		{CurrencyFormatter("¥", 0), int64(-1500), "-¥1,500"},
+		{ParseFormatter("date"), "2024-01-02T15:04:05Z", "2024-01-02"},
+		{ParseFormatter("date:15:04"), time.Date(2024, 1, 2, 9, 30, 0, 0, time.UTC), "09:30"},
+		{ParseFormatter("currency:$"), 3, "$3.00"},
+	}
+	for i, c := range cases {
+		if got := c.format(c.value); got != c.expect {
+			t.Errorf("case %v: expect %q, got %q", i, c.expect, got)
+		}
+	}
+
+	for _, spec := range []string{"money", "number:x", "number:-1"} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("%q: expect panic", spec)
+				}
+			}()
+			ParseFormatter(spec)
+		}()
+	}
+}
+
+func TestBuilderAggregates(t *testing.T) {
+	var (
+		font  = core.Font{Family: TABLE_MD, Size: 10}
+		table *Table
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table = NewTableBuilder(300, 20, font, report).
+			SetColumns(TableColumn{Header: "region"}, TableColumn{Header: "item"}, TableColumn{Header: "amount"}).
+			SetFormatter(2, NumberFormatter(2)).
+			SetGroupBy(0, "%s subtotal").
+			SetAggregates("Total",
+				Aggregate{Col: 1, Func: AGGREGATE_COUNT},
+				Aggregate{Col: 2, Func: AGGREGATE_SUM}).
+			FromStrings([][]string{
+				{"east", "a", "1000"},
+				{"east", "b", "n/a"},
+				{"west", "c", "2.5"},
+				{"west", "", "-1"},
+			})
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	checkContents(t, table, [][]string{
+		{"region", "item", "amount"},
+		{"east", "a", "1,000.00"},
+		{"east", "b", "n/a"},
+		{"east subtotal", "2", "1,000.00"},
+		{"west", "c", "2.50"},
+		{"west", "", "-1.00"},
+		{"west subtotal", "1", "1.50"},
+		{"Total", "3", "1,001.50"},
+	})
+	for _, row := range []int{3, 6, 7} {
+		if table.rowStyles[row].FontStyle != "B" || !table.keepWithNext[row-1] {
+			t.Errorf("row %v: expect bold summary kept with the previous row", row)
+		}
+	}
+	if len(table.rowStyles) != 3 || table.keepWithNext[3] {
+		t.Errorf("expect only summary rows styled, got %v %v", table.rowStyles, table.keepWithNext)
+	}
+}
+
+func TestBuilderAggregateDefaults(t *testing.T) {
+	var table *Table
+	TableReport(func(report *core.Report) {
+		table = NewTableBuilder(300, 20, core.Font{Family: TABLE_MD, Size: 10}, report).
+			SetGroupBy(0, "Subtotal").
+			SetAggregates("Total", Aggregate{Col: 1, Func: AGGREGATE_AVG}).
+			FromStrings([][]string{{"x", "1"}, {"x", "1"}, {"x", "2"}})
+	}).GetBytesPdf()
+
+	// 标签没有 %s 的时候原样写入, avg 默认保留两位小数
+	checkContents(t, table, [][]string{{"x", "1"}, {"x", "1"}, {"x", "2"}, {"Subtotal", "1.33"}, {"Total", "1.33"}})
+}
+
+func TestBuilderAggregateDecimals(t *testing.T) {
+	var table *Table
+	TableReport(func(report *core.Report) {
+		table = NewTableBuilder(300, 20, core.Font{Family: TABLE_MD, Size: 10}, report).
+			SetAggregates("Total",
+				Aggregate{Col: 1, Func: AGGREGATE_SUM},
+				Aggregate{Col: 2, Func: AGGREGATE_MAX},
+				Aggregate{Col: 3, Func: AGGREGATE_SUM}).
+			FromStrings([][]string{{"x", "10.10", "1.5", "1"}, {"y", "20.20", "2.25", "2"}})
+	}).GetBytesPdf()
+
+	// 没有格式的时候, sum, min, max 的小数位数和数据相同
+	checkContents(t, table, [][]string{
+		{"x", "10.10", "1.5", "1"},
+		{"y", "20.20", "2.25", "2"},
+		{"Total", "30.30", "2.25", "3"},
+	})
+}
+
+func TestBuilderAggregateFuncs(t *testing.T) {
+	data := [][]interface{}{{"x", 4}, {"y", "2"}, {"z", nil}, {"w", "n/a"}}
+	cases := []struct {
+		fn     int
+		expect interface{}
+	}{
+		{AGGREGATE_SUM, 6.0},
+		{AGGREGATE_AVG, 3.0},
+		{AGGREGATE_MIN, 2.0},
+		{AGGREGATE_MAX, 4.0},
+		{AGGREGATE_COUNT, 3},
+	}
+	for _, c := range cases {
+		if got, ok := aggregate(c.fn, data, 1); !ok || got != c.expect {
+			t.Errorf("func %v: expect %v, got %v %v", c.fn, c.expect, got, ok)
+		}
+	}
+	if _, ok := aggregate(AGGREGATE_SUM, data, 0); ok {
+		t.Errorf("expect no sum for text column")
+	}
+
+	for i, fn := range []func(){
+		func() { NewTableBuilder(300, 20, core.Font{}, nil).SetAggregates("Total", Aggregate{Col: 0, Func: 9}) },
+		func() { NewTableBuilder(300, 20, core.Font{}, nil).SetGroupBy(-1, "%s") },
+		func() {
+			NewTableBuilder(300, 20, core.Font{}, nil).
+				SetAggregates("Total", Aggregate{Col: 3, Func: AGGREGATE_SUM}).
+				FromStrings([][]string{{"a", "1"}})
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("case %v: expect panic", i)
+				}
+			}()
+			fn()
+		}()
+	}
+}
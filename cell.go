@@ -2,7 +2,6 @@ package gopdf
 
 import (
 	"math"
-	"strings"
 
 	"github.com/tiechui1994/gopdf/core"
 	"github.com/tiechui1994/gopdf/util"
@@ -14,6 +13,7 @@ type TextCell struct {
 	width      float64 // 宽度, 必须
 	height     float64
 	contents   []string // 内容
+	lastLines  []bool   // 段落的最后一行
 	lastheight float64  // 最近一次操作前的height
 
 	lineHeight float64 // 行高
@@ -25,9 +25,15 @@ type TextCell struct {
 	fontColor string
 	backColor string
 
-	verticalCentered   bool // 垂直居中
-	horizontalCentered bool // 水平居中
-	rightAlign         bool // 水平居左
+	verticalCentered   bool   // 垂直居中
+	horizontalCentered bool   // 水平居中
+	rightAlign         bool   // 水平居左
+	justify            bool   // 两端对齐
+	link               string // 链接, 整个单元格是链接区域
+	kinsoku            int    // 禁则处理方式
+	language           string // 断字的语言, 默认使用字体的语言
+	decoration         TextDecoration
+	text               string // 原始内容, 修改宽度之后重新分行
 }
 
 func NewTextCell(width, lineHeight, lineSpace float64, pdf *core.Report) *TextCell {
@@ -57,6 +63,11 @@ func (cell *TextCell) Copy(content string) *TextCell {
 		lineSpace:  cell.lineSpace,
 		border:     cell.border,
 		fontColor:  cell.fontColor,
+		kinsoku:    cell.kinsoku,
+		justify:    cell.justify,
+		link:       cell.link,
+		language:   cell.language,
+		decoration: cell.decoration,
 	}
 
 	text.SetFont(cell.font)
@@ -72,15 +83,59 @@ func (cell *TextCell) VerticalCentered() *TextCell {
 }
 func (cell *TextCell) HorizontalCentered() *TextCell {
 	cell.rightAlign = false
+	cell.justify = false
 	cell.horizontalCentered = true
 	return cell
 }
 func (cell *TextCell) RightAlign() *TextCell {
 	cell.horizontalCentered = false
+	cell.justify = false
 	cell.rightAlign = true
 	return cell
 }
 
+// 两端对齐, 段落的最后一行居左
+func (cell *TextCell) Justify() *TextCell {
+	cell.horizontalCentered = false
+	cell.rightAlign = false
+	cell.justify = true
+	return cell
+}
+
+// 设置文字装饰(下划线, 删除线, 高亮等), 每一行单独绘制
+func (cell *TextCell) SetDecoration(decoration TextDecoration) *TextCell {
+	checkDecoration(decoration)
+	cell.decoration = decoration
+	return cell
+}
+
+// 设置链接, 整个单元格(包括分页之后的部分)是链接区域. link 的格式参考 Report.LinkArea
+func (cell *TextCell) SetLink(link string) *TextCell {
+	cell.link = link
+	return cell
+}
+
+// 设置禁则处理方式, 默认是 KINSOKU_PUSH_OUT, 必须在 SetContent 之前设置
+func (cell *TextCell) SetKinsoku(kinsoku int) *TextCell {
+	checkKinsoku(kinsoku)
+	cell.kinsoku = kinsoku
+	return cell
+}
+
+// 断字器, 组件的语言优先
+func (cell *TextCell) hyphenator() *Hyphenator {
+	if cell.language != "" {
+		return getHyphenator(cell.language)
+	}
+	return getHyphenator(cell.font.Language)
+}
+
+// 设置断字的语言, 必须在 SetContent 之前设置
+func (cell *TextCell) SetLanguage(language string) *TextCell {
+	cell.language = language
+	return cell
+}
+
 func (cell *TextCell) SetFontColor(color string) *TextCell {
 	util.CheckColor(color)
 	cell.fontColor = color
@@ -112,12 +167,6 @@ func (cell *TextCell) SetBorder(border core.Scope) *TextCell {
 }
 
 func (cell *TextCell) SetContent(s string) *TextCell {
-	convertStr := strings.Replace(s, "\t", "    ", -1)
-	var (
-		blocks       = strings.Split(convertStr, "\n") // 分行
-		contentWidth = cell.width - math.Abs(cell.border.Left) - math.Abs(cell.border.Right)
-	)
-
 	// 必须检查字体
 	if util.IsEmpty(cell.font) {
 		panic("there no avliable font")
@@ -126,43 +175,17 @@ func (cell *TextCell) SetContent(s string) *TextCell {
 	// 必须先进行注册, 才能设置
 	cell.pdf.Font(cell.font.Family, cell.font.Size, cell.font.Style)
 	cell.pdf.SetFontWithStyle(cell.font.Family, cell.font.Style, cell.font.Size)
-
-	if len(blocks) == 1 {
-		if cell.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			cell.contents = []string{convertStr}
-			cell.height = math.Abs(cell.border.Top) + math.Abs(cell.border.Bottom) + cell.lineHeight
-			cell.lastheight = cell.height
-			return cell
-		}
+	cell.text = s
+
+	// 按照单词分行
+	contentWidth := cell.width - math.Abs(cell.border.Left) - math.Abs(cell.border.Right)
+	cell.contents, cell.lastLines = lineBreaker{pdf: cell.pdf, width: contentWidth, kinsoku: cell.kinsoku, hyphenator: cell.hyphenator()}.breakLines(s)
+	if len(cell.contents) == 1 {
+		cell.height = math.Abs(cell.border.Top) + math.Abs(cell.border.Bottom) + cell.lineHeight
+		cell.lastheight = cell.height
+		return cell
 	}
 
-	for i := range blocks {
-		// 不需要拆分
-		if cell.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			cell.contents = append(cell.contents, blocks[i])
-			continue
-		}
-
-		// 需要拆分
-		var line []rune
-		for _, r := range []rune(blocks[i]) {
-			line = append(line, r)
-			lineLength := cell.pdf.MeasureTextWidth(string(line))
-			if lineLength >= contentWidth {
-				if lineLength-contentWidth > 2 {
-					cell.contents = append(cell.contents, string(line[0:len(line)-1]))
-					line = line[len(line)-1:]
-				} else {
-					cell.contents = append(cell.contents, string(line))
-					line = []rune{}
-				}
-			}
-		}
-
-		if len(line) > 0 {
-			cell.contents = append(cell.contents, string(line))
-		}
-	}
 	length := float64(len(cell.contents))
 	cell.height = cell.border.Top + math.Abs(cell.border.Bottom) + cell.lineHeight*length + cell.lineSpace*(length-1)
 	cell.lastheight = cell.height
@@ -181,22 +204,23 @@ func (cell *TextCell) GenerateAtomicCell(maxheight float64) (int, int, error) {
 	cell.pdf.SetFontWithStyle(cell.font.Family, cell.font.Style, cell.font.Size)
 
 	// 计算需要打印的行数
-	if maxheight > cell.height || math.Abs(maxheight-cell.height) < 0.01 {
-		lines = len(cell.contents)
-
-		// 垂直居中
-		if maxheight > cell.height && cell.verticalCentered {
-			sy += (maxheight - cell.height) / 2
-		}
-	} else {
-		lines = int((maxheight + cell.lineSpace) / (cell.lineHeight + cell.lineSpace))
-	}
+	lines = cell.fitLines(maxheight)
 
-	// 背景颜色
+	// 背景颜色, 填满 maxheight
 	if !util.IsEmpty(cell.backColor) {
 		cell.pdf.BackgroundColor(sx, sy, cell.width, maxheight, cell.backColor, "0000")
 	}
 
+	// 链接区域
+	if cell.link != "" {
+		cell.pdf.LinkArea(sx, sy, cell.width, maxheight, cell.link)
+	}
+
+	// 垂直居中
+	if maxheight > cell.height && cell.verticalCentered {
+		sy += (maxheight - cell.height) / 2
+	}
+
 	// 写入cell数据
 	for i := 0; i < lines; i++ {
 		width := cell.pdf.MeasureTextWidth(cell.contents[i])
@@ -218,11 +242,16 @@ func (cell *TextCell) GenerateAtomicCell(maxheight float64) (int, int, error) {
 			cell.pdf.TextColor(util.GetColorRGB(cell.fontColor))
 		}
 
-		cell.pdf.Cell(x, y, cell.contents[i])
+		justify := cell.justify && !cell.lastLines[i]
+		lineWidth := cell.width - math.Abs(cell.border.Left) - math.Abs(cell.border.Right)
+		extent := lineExtent(cell.pdf, cell.contents[i], lineWidth, cell.kinsoku, justify)
+		cell.decoration.drawHighlight(cell.pdf, x, y, extent, cell.font)
+		writeLine(cell.pdf, x, y, cell.contents[i], lineWidth, cell.kinsoku, justify)
 
 		if !util.IsEmpty(cell.fontColor) {
 			cell.pdf.TextDefaultColor()
 		}
+		cell.decoration.drawLines(cell.pdf, x, y, extent, cell.font)
 	}
 
 	// 重置lastheight
@@ -231,8 +260,10 @@ func (cell *TextCell) GenerateAtomicCell(maxheight float64) (int, int, error) {
 	// cell的height和contents重置
 	if lines >= len(cell.contents) {
 		cell.contents = nil
+		cell.lastLines = nil
 	} else {
 		cell.contents = cell.contents[lines:]
+		cell.lastLines = cell.lastLines[lines:]
 	}
 
 	if len(cell.contents) == 0 {
@@ -254,11 +285,7 @@ func (cell *TextCell) TryGenerateAtomicCell(maxheight float64) (int, int) {
 	cell.pdf.SetFontWithStyle(cell.font.Family, cell.font.Style, cell.font.Size)
 
 	// 计算需要打印的行数
-	if maxheight > cell.height || math.Abs(maxheight-cell.height) < 0.01 {
-		lines = len(cell.contents)
-	} else {
-		lines = int((maxheight + cell.lineSpace) / (cell.lineHeight + cell.lineSpace))
-	}
+	lines = cell.fitLines(maxheight)
 
 	remain := 0
 	if lines < len(cell.contents) {
@@ -268,6 +295,67 @@ func (cell *TextCell) TryGenerateAtomicCell(maxheight float64) (int, int) {
 	return lines, remain
 }
 
+// maxheight 的高度内可以写入的行数, 行从上边框之后开始
+func (cell *TextCell) fitLines(maxheight float64) int {
+	if maxheight > cell.height || math.Abs(maxheight-cell.height) < 0.01 {
+		return len(cell.contents)
+	}
+
+	lines := int((maxheight - cell.border.Top + cell.lineSpace) / (cell.lineHeight + cell.lineSpace))
+	if lines < 0 {
+		return 0
+	}
+	return lines
+}
+
+// 内容的最小宽度(最宽的不可分割的片段)和最大宽度(不换行), 包括左右的边框. 用于自动列宽
+func (cell *TextCell) MeasureWidth() (min, max float64) {
+	cell.pdf.Font(cell.font.Family, cell.font.Size, cell.font.Style)
+	cell.pdf.SetFontWithStyle(cell.font.Family, cell.font.Style, cell.font.Size)
+
+	min, max = lineBreaker{pdf: cell.pdf}.measureContent(cell.text)
+	border := math.Abs(cell.border.Left) + math.Abs(cell.border.Right)
+	return min + border, max + border
+}
+
+// 修改宽度, 已经设置的内容重新分行
+func (cell *TextCell) Resize(width float64) {
+	cell.width = width
+	if cell.contents != nil {
+		cell.SetContent(cell.text)
+	}
+}
+
+// 原始内容
+func (cell *TextCell) GetContent() string {
+	return cell.text
+}
+
+// 应用表格的样式, 字体风格变化的时候重新分行
+func (cell *TextCell) ApplyStyle(style CellStyle) {
+	if style.BackColor != "" {
+		cell.SetBackColor(style.BackColor)
+	}
+	if style.FontColor != "" {
+		cell.SetFontColor(style.FontColor)
+	}
+
+	font := cell.font
+	font.Style = CellStyle{FontStyle: font.Style}.merge(CellStyle{FontStyle: style.FontStyle}).FontStyle
+	if font.Style != cell.font.Style {
+		cell.SetFont(font)
+		if cell.contents != nil {
+			cell.SetContent(cell.text)
+		}
+	}
+}
+
+// 复制当前的状态(包括没有写入的内容), 用于表头和表尾的重复写入
+func (cell *TextCell) CopyCell() core.Cell {
+	text := *cell
+	return &text
+}
+
 func (cell *TextCell) GetHeight() float64 {
 	return cell.height
 }
@@ -0,0 +1,287 @@
+package gopdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+/**
+Table的列宽.
+
+列宽的格式:
+	"120"  绝对宽度, 单位是 point
+	"25%"  table 宽度的百分比
+	"2*"   权重, 分配剩余的宽度, "*" 等价于 "1*"
+	"auto" 按照单元格的内容计算宽度(类似 HTML 的自动布局)
+
+先分配绝对宽度和百分比, 剩余的宽度分配给 auto 列和权重列. auto 列的宽度在最小宽度(最宽的不可分割的片段)
+和最大宽度(内容不换行)之间, 存在权重列的时候 auto 列最多使用最大宽度, 其余的宽度分配给权重列.
+没有 auto 列和权重列的时候, table 的宽度是所有列宽度之和.
+
+auto 列需要单元格的元素实现 ResizableCell, 在 GenerateAtomicCell 的时候计算列宽, 并修改元素的宽度.
+**/
+
+const (
+	columnPoint   = 1 // 绝对宽度
+	columnPercent = 2 // 百分比
+	columnWeight  = 3 // 权重
+	columnAuto    = 4 // 按照内容计算
+)
+
+// 可以按照内容修改宽度的单元格元素
+type ResizableCell interface {
+	core.Cell
+	MeasureWidth() (min, max float64) // 最小宽度和最大宽度
+	Resize(width float64)             // 修改宽度, 重新分行
+}
+
+type column struct {
+	kind  int
+	value float64
+}
+
+func parseColumn(width string) column {
+	width = strings.TrimSpace(width)
+	var (
+		kind  = columnPoint
+		value = width
+	)
+	switch {
+	case width == "auto":
+		return column{kind: columnAuto}
+	case width == "*":
+		return column{kind: columnWeight, value: 1}
+	case strings.HasSuffix(width, "%"):
+		kind, value = columnPercent, strings.TrimSuffix(width, "%")
+	case strings.HasSuffix(width, "*"):
+		kind, value = columnWeight, strings.TrimSuffix(width, "*")
+	}
+
+	v, err := strconv.ParseFloat(value, 64)
+	if err != nil || v < 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		panic("invalid column width: " + width)
+	}
+	return column{kind: kind, value: v}
+}
+
+// 设置列宽, 格式参考 column.go
+func (table *Table) SetColumnWidths(widths ...string) {
+	if len(widths) != table.cols {
+		panic("the number of column widths must be equal to cols")
+	}
+
+	columns := make([]column, len(widths))
+	for i, width := range widths {
+		columns[i] = parseColumn(width)
+	}
+
+	table.columns = columns
+	table.resolveColumns(nil, nil)
+}
+
+// 按照内容计算 auto 列的宽度, 然后修改所有单元格元素的宽度(元素可能在设置列宽之前创建).
+// 没有设置列宽的表格使用默认的平均列宽, 同样需要修改元素的宽度
+func (table *Table) layoutColumns() {
+	for _, c := range table.columns {
+		if c.kind == columnAuto || table.paging {
+			table.resolveColumns(table.measureColumns())
+			break
+		}
+	}
+
+	for i := 0; i < table.rows; i++ {
+		for j := 0; j < table.cols; j++ {
+			cell := table.cells[i][j]
+			if cell == nil || cell.element == nil {
+				continue
+			}
+
+			if e, ok := cell.element.(ResizableCell); ok && cell.rowspan >= 1 {
+				e.Resize(table.GetColWidth(i, j))
+			}
+		}
+	}
+}
+
+// auto 列的最小宽度和最大宽度. 先计算单列的单元格, 跨列的单元格把不足的部分平均分配到跨越的 auto 列
+func (table *Table) measureColumns() (mins, maxs []float64) {
+	mins, maxs = make([]float64, table.cols), make([]float64, table.cols)
+
+	var spans []*TableCell
+	for i := 0; i < table.rows; i++ {
+		for j := 0; j < table.cols; j++ {
+			cell := table.cells[i][j]
+			if cell == nil || cell.rowspan < 1 || cell.element == nil {
+				continue
+			}
+			if _, ok := cell.element.(ResizableCell); !ok {
+				continue
+			}
+
+			if cell.colspan > 1 {
+				spans = append(spans, cell)
+				continue
+			}
+
+			min, max := cell.element.(ResizableCell).MeasureWidth()
+			mins[j] = math.Max(mins[j], min)
+			maxs[j] = math.Max(maxs[j], max)
+		}
+	}
+
+	for _, cell := range spans {
+		var (
+			min, max         = cell.element.(ResizableCell).MeasureWidth()
+			autos            []int
+			spanMin, spanMax float64
+		)
+		for k := cell.col; k < cell.col+cell.colspan; k++ {
+			if table.columns[k].kind == columnAuto {
+				autos = append(autos, k)
+				spanMin += mins[k]
+				spanMax += maxs[k]
+			} else {
+				spanMin += table.colwidths[k] * table.width
+				spanMax += table.colwidths[k] * table.width
+			}
+		}
+		if len(autos) == 0 {
+			continue
+		}
+
+		for _, k := range autos {
+			if min > spanMin {
+				mins[k] += (min - spanMin) / float64(len(autos))
+			}
+			if max > spanMax {
+				maxs[k] += (max - spanMax) / float64(len(autos))
+			}
+		}
+	}
+
+	for k := range maxs {
+		maxs[k] = math.Max(maxs[k], mins[k])
+	}
+
+	return mins, maxs
+}
+
+// 计算列宽. mins, maxs 是 auto 列的最小宽度和最大宽度, 为 nil 的时候 auto 列按照权重 1 处理
+func (table *Table) resolveColumns(mins, maxs []float64) {
+	if table.paging {
+		contentWidth, _ := table.pdf.GetContentWidthAndHeight()
+		table.resolvePagingColumns(maxs, contentWidth)
+		return
+	}
+
+	var (
+		widths = make([]float64, table.cols)
+		fixed  float64
+		weight float64
+		autos  []int
+	)
+
+	// 每次都从设置的宽度开始计算, 避免上一次的结果累积
+	table.width = table.baseWidth
+	for i, c := range table.columns {
+		switch {
+		case c.kind == columnPoint:
+			widths[i] = c.value
+			fixed += c.value
+		case c.kind == columnPercent:
+			widths[i] = c.value / 100 * table.width
+			fixed += widths[i]
+		case c.kind == columnWeight:
+			weight += c.value
+		case mins == nil:
+			weight += 1
+		default:
+			autos = append(autos, i)
+		}
+	}
+
+	rest := table.width - fixed
+	if rest < -0.01 {
+		panic("the column widths exceed the table width")
+	}
+
+	// 没有需要分配剩余宽度的列
+	if weight == 0 && len(autos) == 0 {
+		table.width = fixed
+	}
+
+	if len(autos) > 0 {
+		var autoMins, autoMaxs []float64
+		for _, i := range autos {
+			autoMins = append(autoMins, mins[i])
+			autoMaxs = append(autoMaxs, maxs[i])
+		}
+
+		available := rest
+		if weight > 0 {
+			available = math.Min(rest, sumWidths(autoMaxs))
+		}
+		for k, w := range distributeWidths(autoMins, autoMaxs, available) {
+			widths[autos[k]] = w
+			rest -= w
+		}
+	}
+
+	for i, c := range table.columns {
+		switch {
+		case c.kind == columnWeight && weight > 0:
+			widths[i] = math.Max(rest, 0) * c.value / weight
+		case c.kind == columnAuto && mins == nil:
+			widths[i] = math.Max(rest, 0) / weight
+		}
+	}
+
+	for i := range widths {
+		if table.width > 0 {
+			table.colwidths[i] = widths[i] / table.width
+		}
+	}
+	table.cachedCol = nil
+}
+
+// 类似 HTML 的自动布局, 将 width 分配到各列
+func distributeWidths(mins, maxs []float64, width float64) []float64 {
+	var (
+		widths         = make([]float64, len(mins))
+		sumMin, sumMax = sumWidths(mins), sumWidths(maxs)
+	)
+
+	switch {
+	case sumMax <= width:
+		// 最大宽度都可以满足, 剩余的宽度按照最大宽度的比例分配
+		for i := range widths {
+			if sumMax > 0 {
+				widths[i] = maxs[i] + (width-sumMax)*maxs[i]/sumMax
+			} else {
+				widths[i] = width / float64(len(widths))
+			}
+		}
+	case sumMin <= width:
+		// 在最小宽度的基础上, 按照 max-min 的比例分配
+		for i := range widths {
+			widths[i] = mins[i] + (maxs[i]-mins[i])*(width-sumMin)/(sumMax-sumMin)
+		}
+	default:
+		// 最小宽度也无法满足, 按照最小宽度的比例压缩
+		for i := range widths {
+			widths[i] = mins[i] * width / sumMin
+		}
+	}
+
+	return widths
+}
+
+func sumWidths(values []float64) (total float64) {
+	for _, v := range values {
+		total += v
+	}
+	return total
+}
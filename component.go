@@ -0,0 +1,390 @@
+package gopdf
+
+import (
+	"math"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+/**
+表格单元格当中的组件.
+
+ComponentCell 是组件的 core.Cell 适配器, 支持 Table(嵌套的表格), Div, RichText 和 Block(Image, SVGImage,
+条形码等高度固定的组件). 跨页的时候, Table 按照行拆分(每个片段重复表头和表尾), Div 和 RichText 按照行拆分,
+Block 不能拆分, 整体移到下一页. 比整个页面还高, 不能拆分的内容在页面的顶部写入, 超出页面的部分被裁剪.
+
+组件的宽度在表格计算列宽的时候修改为列宽减去左右的内边距(Block 除外), 组件自身的外边距保留.
+**/
+
+// 高度固定, 不能拆分的组件. 在当前的位置写入
+type Block interface {
+	GetWidth() float64
+	GetHeight() float64
+	GenerateAtomicCell() error
+}
+
+// 组件的拆分和写入
+type cellComponent interface {
+	remainHeight() float64                                      // 剩余内容的高度
+	fit(maxheight float64) (height float64, writed, remain int) // maxheight 之内可以写入的高度和行数, 以及剩余的行数
+	writeFragment(x, y, maxheight float64)                      // 在 (x, y) 写入 fit(maxheight) 的内容
+	firstHeight() float64                                       // 第一部分(第一行, Block 是整体)的高度
+	measureWidth() (min, max float64)
+	resize(width float64)
+}
+
+// 组件的 core.Cell 适配器, 用于 TableCell.SetElement. 写入的行数和剩余的行数: Table 是表格主体的行,
+// Div 和 RichText 是文字的行, Block 是 1
+type ComponentCell struct {
+	pdf        *core.Report
+	component  cellComponent
+	padding    core.Scope
+	lastHeight float64
+}
+
+// component 是 *Table, *Div, *RichText 或者 Block
+func NewComponentCell(component interface{}, pdf *core.Report) *ComponentCell {
+	cell := &ComponentCell{pdf: pdf}
+	switch c := component.(type) {
+	case *Table:
+		cell.component = &tableComponent{table: c}
+	case *Div:
+		cell.component = &divComponent{div: c}
+	case *RichText:
+		cell.component = &richTextComponent{rt: c}
+	case Block:
+		cell.component = &blockComponent{block: c, pdf: pdf}
+	default:
+		panic("unsupported component")
+	}
+	return cell
+}
+
+// 组件和单元格边框之间的距离
+func (cell *ComponentCell) SetPadding(padding core.Scope) *ComponentCell {
+	padding.ReplaceBorder()
+	cell.padding = padding
+	return cell
+}
+
+func (cell *ComponentCell) GenerateAtomicCell(maxheight float64) (int, int, error) {
+	cell.lastHeight = cell.GetHeight()
+
+	var (
+		x, y       = cell.pdf.GetXY()
+		endX, endY = cell.pdf.GetPageEndXY()
+		inner      = maxheight - cell.padding.Top - cell.padding.Bottom
+	)
+
+	// 页面的顶部也写不下的时候表格强制写入整行(超出页面), 只写入第一部分, 超出页面的部分裁剪掉
+	clip := y+maxheight > endY+0.01
+	if clip {
+		inner = math.Min(inner, cell.component.firstHeight())
+	}
+
+	_, writed, remain := cell.component.fit(inner)
+	if writed > 0 && clip {
+		cell.pdf.Clip(x, y, endX-x, endY-y)
+	}
+	if writed > 0 {
+		cell.component.writeFragment(x+cell.padding.Left, y+cell.padding.Top, inner)
+	}
+	if writed > 0 && clip {
+		cell.pdf.ClipReset()
+	}
+	cell.pdf.SetXY(x, y)
+
+	return writed, remain, nil
+}
+
+func (cell *ComponentCell) TryGenerateAtomicCell(maxheight float64) (int, int) {
+	_, writed, remain := cell.component.fit(maxheight - cell.padding.Top - cell.padding.Bottom)
+	return writed, remain
+}
+
+func (cell *ComponentCell) GetHeight() float64 {
+	height := cell.component.remainHeight()
+	if height <= 0 {
+		return 0
+	}
+	return height + cell.padding.Top + cell.padding.Bottom
+}
+
+func (cell *ComponentCell) GetLastHeight() float64 {
+	return cell.lastHeight
+}
+
+func (cell *ComponentCell) MeasureWidth() (min, max float64) {
+	min, max = cell.component.measureWidth()
+	padding := cell.padding.Left + cell.padding.Right
+	return min + padding, max + padding
+}
+
+func (cell *ComponentCell) Resize(width float64) {
+	width -= cell.padding.Left + cell.padding.Right
+	if width > 0 {
+		cell.component.resize(width)
+	}
+}
+
+// 嵌套的表格, 按照行拆分
+type tableComponent struct {
+	table *Table
+}
+
+func (c *tableComponent) remainHeight() float64 {
+	table := c.table
+	table.prepare()
+	if table.start >= table.end {
+		return 0
+	}
+	return table.margin.Top + table.headerHeight + table.getRowsHeight() + table.footerHeight + table.margin.Bottom
+}
+
+func (c *tableComponent) fit(maxheight float64) (height float64, writed, remain int) {
+	table := c.table
+	table.prepare()
+	if table.start >= table.end {
+		return 0, 0, 0
+	}
+
+	top := table.margin.Top + table.headerHeight
+	layout := table.layoutPage(top, maxheight-table.footerHeight-table.margin.Bottom, false)
+	remain = table.end - layout.end
+	if layout.split {
+		remain++
+	}
+	if layout.end == layout.start {
+		return 0, 0, remain
+	}
+	return layout.bottom + table.footerHeight + table.margin.Bottom, layout.end - layout.start, remain
+}
+
+func (c *tableComponent) firstHeight() float64 {
+	table := c.table
+	table.prepare()
+	if table.start >= table.end {
+		return 0
+	}
+
+	top := table.margin.Top + table.headerHeight
+	layout := table.layoutPage(top, math.MaxFloat64, false)
+	return top + layout.heights[0] + table.footerHeight + table.margin.Bottom
+}
+
+func (c *tableComponent) writeFragment(x, y, maxheight float64) {
+	table := c.table
+	table.writeFragment(x, y+table.margin.Top, y+maxheight-table.footerHeight-table.margin.Bottom, false)
+}
+
+func (c *tableComponent) measureWidth() (min, max float64) {
+	width := c.table.width + c.table.margin.Left + c.table.margin.Right
+	return width, width
+}
+
+// 修改表格的宽度. 没有设置列宽的时候, 保持当前列宽的比例
+func (c *tableComponent) resize(width float64) {
+	table := c.table
+	width -= table.margin.Left + table.margin.Right
+	if width <= 0 {
+		return
+	}
+
+	if table.columns == nil {
+		table.columns = make([]column, table.cols)
+		for i := range table.columns {
+			table.columns[i] = column{kind: columnPercent, value: table.colwidths[i] * 100}
+		}
+	}
+	table.width, table.baseWidth = width, width
+	table.resolveColumns(nil, nil)
+}
+
+// Div, 按照行拆分
+type divComponent struct {
+	div *Div
+}
+
+// 前 lines 行的高度
+func (c *divComponent) height(lines int) float64 {
+	div := c.div
+	if lines == 0 {
+		return 0
+	}
+	return div.margin.Top + div.border.Top + div.lineHeight*float64(lines) + div.lineSpace*float64(lines-1) +
+		div.border.Bottom + div.margin.Bottom
+}
+
+func (c *divComponent) remainHeight() float64 {
+	return c.height(len(c.div.contents))
+}
+
+// maxheight 之内可以写入的行数
+func (c *divComponent) fitLines(maxheight float64) int {
+	lines := len(c.div.contents)
+	for lines > 0 && c.height(lines) > maxheight+0.01 {
+		lines--
+	}
+	return lines
+}
+
+func (c *divComponent) fit(maxheight float64) (height float64, writed, remain int) {
+	lines := c.fitLines(maxheight)
+	return c.height(lines), lines, len(c.div.contents) - lines
+}
+
+func (c *divComponent) firstHeight() float64 {
+	return c.height(1)
+}
+
+// 使用前面的行构造新的 Div 写入, 剩余的行保留
+func (c *divComponent) writeFragment(x, y, maxheight float64) {
+	var (
+		div   = c.div
+		lines = c.fitLines(maxheight)
+		part  = *div
+	)
+	part.contents, part.lastLines = div.contents[:lines], div.lastLines[:lines]
+	part.resetHeight()
+
+	div.pdf.SetXY(x, y)
+	part.GenerateAtomicCell()
+	div.contents, div.lastLines = div.contents[lines:], div.lastLines[lines:]
+	div.resetHeight()
+}
+
+func (c *divComponent) extra() float64 {
+	div := c.div
+	return div.margin.Left + div.margin.Right + math.Abs(div.border.Left) + math.Abs(div.border.Right)
+}
+
+func (c *divComponent) measureWidth() (min, max float64) {
+	div := c.div
+	div.pdf.Font(div.font.Family, div.font.Size, div.font.Style)
+	div.pdf.SetFontWithStyle(div.font.Family, div.font.Style, div.font.Size)
+
+	min, max = lineBreaker{pdf: div.pdf}.measureContent(div.text)
+	return min + c.extra(), max + c.extra()
+}
+
+func (c *divComponent) resize(width float64) {
+	div := c.div
+	if width-c.extra() <= 0 {
+		return
+	}
+	div.width = width - c.extra()
+	if div.contents != nil {
+		div.SetContent(div.text)
+	}
+}
+
+// RichText, 按照行拆分
+type richTextComponent struct {
+	rt *RichText
+}
+
+// 前 lines 行的高度
+func (c *richTextComponent) height(lines int) float64 {
+	rt := c.rt
+	if lines == 0 {
+		return 0
+	}
+
+	height := rt.margin.Top + rt.margin.Bottom + rt.lineSpace*float64(lines-1)
+	for _, line := range rt.lines[:lines] {
+		height += line.ascent + line.descent
+	}
+	return height
+}
+
+func (c *richTextComponent) remainHeight() float64 {
+	return c.height(len(c.rt.lines))
+}
+
+func (c *richTextComponent) fitLines(maxheight float64) int {
+	lines := len(c.rt.lines)
+	for lines > 0 && c.height(lines) > maxheight+0.01 {
+		lines--
+	}
+	return lines
+}
+
+func (c *richTextComponent) fit(maxheight float64) (height float64, writed, remain int) {
+	lines := c.fitLines(maxheight)
+	return c.height(lines), lines, len(c.rt.lines) - lines
+}
+
+func (c *richTextComponent) firstHeight() float64 {
+	return c.height(1)
+}
+
+func (c *richTextComponent) writeFragment(x, y, maxheight float64) {
+	var (
+		rt    = c.rt
+		lines = c.fitLines(maxheight)
+		part  = *rt
+	)
+	part.lines = rt.lines[:lines]
+
+	rt.pdf.SetXY(x, y)
+	part.GenerateAtomicCell()
+	rt.lines = rt.lines[lines:]
+	rt.height = c.height(len(rt.lines)) - rt.margin.Top - rt.margin.Bottom
+}
+
+func (c *richTextComponent) measureWidth() (min, max float64) {
+	width := c.rt.width + c.rt.margin.Left + c.rt.margin.Right
+	return width, width
+}
+
+func (c *richTextComponent) resize(width float64) {
+	rt := c.rt
+	width -= rt.margin.Left + rt.margin.Right
+	if width <= 0 {
+		return
+	}
+	rt.width = width
+	if rt.lines != nil {
+		rt.SetContent(rt.runs...)
+	}
+}
+
+// 高度固定的组件, 不能拆分
+type blockComponent struct {
+	pdf   *core.Report
+	block Block
+	done  bool
+}
+
+func (c *blockComponent) remainHeight() float64 {
+	if c.done {
+		return 0
+	}
+	return c.block.GetHeight()
+}
+
+func (c *blockComponent) fit(maxheight float64) (height float64, writed, remain int) {
+	switch {
+	case c.done:
+		return 0, 0, 0
+	case c.block.GetHeight() > maxheight+0.01:
+		return 0, 0, 1
+	}
+	return c.block.GetHeight(), 1, 0
+}
+
+func (c *blockComponent) firstHeight() float64 {
+	return c.remainHeight()
+}
+
+func (c *blockComponent) writeFragment(x, y, maxheight float64) {
+	c.pdf.SetXY(x, y)
+	c.block.GenerateAtomicCell()
+	c.done = true
+}
+
+func (c *blockComponent) measureWidth() (min, max float64) {
+	return c.block.GetWidth(), c.block.GetWidth()
+}
+
+func (c *blockComponent) resize(width float64) {}
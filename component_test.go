@@ -0,0 +1,348 @@
+package gopdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+type componentText struct {
+	page    int
+	x, y    float64
+	content string
+}
+
+// 所有写入的文字, 按照写入的顺序
+func componentTexts(cells []string) (texts []componentText) {
+	page := 0
+	for _, cell := range cells {
+		if cell == "NP" {
+			page++
+			continue
+		}
+		if strings.HasPrefix(cell, "CL|") {
+			tokens := strings.SplitN(cell, "|", 4)
+			x, _ := strconv.ParseFloat(tokens[1], 64)
+			y, _ := strconv.ParseFloat(tokens[2], 64)
+			texts = append(texts, componentText{page: page, x: x, y: y, content: tokens[3]})
+		}
+	}
+	return texts
+}
+
+func TestComponentNestedTable(t *testing.T) {
+	var (
+		font        = core.Font{Family: TABLE_MD, Size: 10}
+		items       = 70
+		x, pageEndY float64
+		nested      *Table
+	)
+
+	r := TableReport(func(report *core.Report) {
+		x, _ = report.GetXY()
+		_, pageEndY = report.GetPageEndXY()
+		text := func(s string) *TextCell {
+			return NewTextCell(100, 18, 0, report).SetFont(font).SetContent(s)
+		}
+
+		nested = NewTable(2, items+1, 999, 18, report)
+		nested.SetHeaderRows(1)
+		nested.NewCell().SetElement(text("Item"))
+		nested.NewCell().SetElement(text("Amount"))
+		for i := 0; i < items; i++ {
+			nested.NewCell().SetElement(text(fmt.Sprintf("item-%d", i)))
+			nested.NewCell().SetElement(text(strconv.Itoa(i * 10)))
+		}
+
+		table := NewTable(2, 3, 400, 18, report)
+		table.SetColumnWidths("100", "*")
+		table.NewCell().SetElement(text("Invoice"))
+		table.NewCell().SetElement(text("No. 1"))
+		table.NewCell().SetElement(text("Items"))
+		table.NewCell().SetElement(NewComponentCell(nested, report).SetPadding(core.Scope{Left: 5, Top: 5, Right: 5, Bottom: 5}))
+		table.NewCell().SetElement(text("Total"))
+		table.NewCell().SetElement(text("end"))
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	if nested.width != 290 {
+		t.Errorf("expect nested width 290, got %v", nested.width)
+	}
+
+	var (
+		texts   = componentTexts(*r.GetAtomicCells())
+		next    int
+		headers = map[int]bool{}
+		pages   = map[int]bool{}
+		last    componentText
+	)
+	for _, text := range texts {
+		switch {
+		case text.content == "Item":
+			headers[text.page] = true
+			if text.x < x+105-0.01 {
+				t.Errorf("nested header outside the cell: %+v", text)
+			}
+		case strings.HasPrefix(text.content, "item-"):
+			if text.content != fmt.Sprintf("item-%d", next) {
+				t.Fatalf("expect item-%d, got %v", next, text.content)
+			}
+			if text.x < x+105-0.01 || text.y+18 > pageEndY+0.01 {
+				t.Errorf("item outside the cell or page: %+v", text)
+			}
+			next++
+			pages[text.page] = true
+			last = text
+		case text.content == "Total":
+			if text.page != last.page || text.y <= last.y {
+				t.Errorf("expect total after the last item, got %+v %+v", text, last)
+			}
+		}
+	}
+
+	if next != items {
+		t.Errorf("expect %v items, got %v", items, next)
+	}
+	if len(pages) < 2 {
+		t.Errorf("expect items across pages, got %v", pages)
+	}
+	for page := range pages {
+		if !headers[page] {
+			t.Errorf("page %v: nested header is missing", page)
+		}
+	}
+}
+
+func TestComponentDivSplit(t *testing.T) {
+	var (
+		font     = core.Font{Family: TABLE_MD, Size: 10}
+		pageEndY float64
+		lines    int
+		runs     int
+		content  = strings.Repeat("a long paragraph inside a table cell. ", 30)
+	)
+
+	r := TableReport(func(report *core.Report) {
+		_, pageEndY = report.GetPageEndXY()
+		x, _ := report.GetXY()
+		report.SetXY(x, pageEndY-100)
+
+		div := NewDivWithWidth(999, 14, 2, report).SetFont(font)
+		div.SetContent(content)
+		rt := NewRichTextWithWidth(999, 2, report).SetContent(TextRun{Text: content, Font: font})
+
+		table := NewTable(2, 1, 400, 18, report)
+		table.NewCell().SetElement(NewComponentCell(div, report))
+		table.NewCell().SetElement(NewComponentCell(rt, report))
+		table.GenerateAtomicCell()
+		lines, runs = len(div.contents), len(rt.lines)
+	})
+	r.GetBytesPdf()
+
+	if lines != 0 || runs != 0 {
+		t.Errorf("expect all lines written, remain %v %v", lines, runs)
+	}
+
+	var pages = map[int]int{}
+	for _, text := range componentTexts(*r.GetAtomicCells()) {
+		if text.y+14 > pageEndY+0.01 {
+			t.Errorf("text beyond the page end: %+v", text)
+		}
+		pages[text.page]++
+	}
+	if len(pages) != 2 {
+		t.Errorf("expect 2 pages, got %v", pages)
+	}
+}
+
+// 没有设置列宽的表格, 组件的宽度同样修改为列宽
+func TestComponentDefaultColumns(t *testing.T) {
+	var (
+		font   = core.Font{Family: TABLE_MD, Size: 10}
+		nested *Table
+		div    *Div
+		x      float64
+	)
+
+	r := TableReport(func(report *core.Report) {
+		x, _ = report.GetXY()
+		text := func(s string) *TextCell {
+			return NewTextCell(250, 18, 0, report).SetFont(font).SetContent(s)
+		}
+
+		nested = NewTable(2, 1, 250, 18, report)
+		nested.NewCell().SetElement(text("left"))
+		nested.NewCell().SetElement(text("right"))
+		div = NewDivWithWidth(250, 14, 2, report).SetFont(font)
+		div.SetContent(strings.Repeat("a long paragraph inside a narrow column. ", 3))
+
+		table := NewTable(2, 1, 200, 18, report)
+		table.NewCell().SetElement(NewComponentCell(nested, report).SetPadding(core.Scope{Left: 5, Right: 5}))
+		table.NewCell().SetElement(NewComponentCell(div, report))
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	if nested.width != 90 || div.width > 100 {
+		t.Errorf("expect components resized to the columns, got %v %v", nested.width, div.width)
+	}
+	for _, text := range componentTexts(*r.GetAtomicCells()) {
+		if text.content == "right" && (text.x < x+50-0.01 || text.x > x+100) {
+			t.Errorf("nested cell outside the column: %+v", text)
+		}
+	}
+}
+
+// 测试使用的条形码, 写入一个矩形
+type componentBarcode struct {
+	pdf *core.Report
+}
+
+func (b componentBarcode) GetWidth() float64  { return 80 }
+func (b componentBarcode) GetHeight() float64 { return 40 }
+func (b componentBarcode) GenerateAtomicCell() error {
+	x, y := b.pdf.GetXY()
+	b.pdf.BackgroundColor(x, y, 80, 40, "0,0,0", "0000")
+	return nil
+}
+
+func TestComponentBlock(t *testing.T) {
+	var (
+		font           = core.Font{Family: TABLE_MD, Size: 10}
+		startY, pageEY float64
+	)
+
+	r := TableReport(func(report *core.Report) {
+		_, startY = report.GetPageStartXY()
+		_, pageEY = report.GetPageEndXY()
+		x, _ := report.GetXY()
+		report.SetXY(x, pageEY-30)
+
+		table := NewTable(2, 1, 400, 18, report)
+		table.NewCell().SetElement(NewTextCell(200, 18, 0, report).SetFont(font).SetContent("barcode"))
+		table.NewCell().SetElement(NewComponentCell(componentBarcode{pdf: report}, report))
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	var (
+		page  int
+		found bool
+	)
+	for _, cell := range *r.GetAtomicCells() {
+		if cell == "NP" {
+			page++
+		}
+		if strings.HasPrefix(cell, "BC|") && strings.Contains(cell, "|0|0|0|") {
+			tokens := strings.Split(cell, "|")
+			y, _ := strconv.ParseFloat(tokens[2], 64)
+			if page != 1 || y != startY {
+				t.Errorf("expect barcode at the top of the next page, got page %v y %v", page, y)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("barcode is missing")
+	}
+}
+
+// 比页面还高的条形码
+type componentTallBarcode struct {
+	pdf *core.Report
+}
+
+func (b componentTallBarcode) GetWidth() float64  { return 80 }
+func (b componentTallBarcode) GetHeight() float64 { return 2000 }
+func (b componentTallBarcode) GenerateAtomicCell() error {
+	x, y := b.pdf.GetXY()
+	b.pdf.BackgroundColor(x, y, 80, 2000, "0,0,0", "0000")
+	return nil
+}
+
+func TestComponentOversized(t *testing.T) {
+	var (
+		font   = core.Font{Family: TABLE_MD, Size: 10}
+		pageEY float64
+	)
+
+	r := TableReport(func(report *core.Report) {
+		_, pageEY = report.GetPageEndXY()
+
+		// 直接写入的 Block 和嵌套表格当中的 Block
+		table := NewTable(2, 2, 400, 18, report)
+		table.NewCell().SetElement(NewTextCell(200, 18, 0, report).SetFont(font).SetContent("block"))
+		table.NewCell().SetElement(NewComponentCell(componentTallBarcode{pdf: report}, report))
+
+		inner := NewTable(1, 2, 150, 18, report)
+		inner.NewCell().SetElement(NewComponentCell(componentTallBarcode{pdf: report}, report))
+		inner.NewCell().SetElement(NewTextCell(150, 18, 0, report).SetFont(font).SetContent("after"))
+		table.NewCell().SetElement(NewTextCell(200, 18, 0, report).SetFont(font).SetContent("nested"))
+		table.NewCell().SetElement(NewComponentCell(inner, report))
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	var (
+		page    int
+		clips   []float64 // 裁剪区域的底部
+		clipped bool
+		bars    []int // 条形码所在的页
+	)
+	for _, cell := range *r.GetAtomicCells() {
+		tokens := strings.Split(cell, "|")
+		switch tokens[0] {
+		case "NP":
+			page++
+		case "CS":
+			y, _ := strconv.ParseFloat(tokens[2], 64)
+			h, _ := strconv.ParseFloat(tokens[4], 64)
+			clips = append(clips, y+h)
+			clipped = true
+		case "CE":
+			clipped = false
+		case "BC":
+			if strings.Contains(cell, "|0|0|0|") {
+				if !clipped {
+					t.Errorf("barcode on page %v is not clipped", page)
+				}
+				bars = append(bars, page)
+			}
+		}
+	}
+
+	if len(bars) != 2 || bars[0] == bars[1] {
+		t.Fatalf("expect 2 barcodes on different pages, got %v", bars)
+	}
+	for _, bottom := range clips {
+		if bottom > pageEY+0.01 {
+			t.Errorf("clip %v is below the page end %v", bottom, pageEY)
+		}
+	}
+
+	// 嵌套表格剩余的行在下一页继续
+	var texts []string
+	for _, text := range componentTexts(*r.GetAtomicCells()) {
+		texts = append(texts, text.content)
+		if text.content == "after" && (text.page <= bars[1] || text.y > pageEY) {
+			t.Errorf("expect the rest of the nested table on the next page, got page %v y %v", text.page, text.y)
+		}
+	}
+	if strings.Join(texts, ",") != "block,nested,after" {
+		t.Errorf("texts: %v", texts)
+	}
+}
+
+func TestComponentInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for unsupported component")
+		}
+	}()
+
+	NewComponentCell("text", core.CreateReport())
+}
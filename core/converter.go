@@ -3,15 +3,23 @@ package core
 import (
 	"fmt"
 	"io/ioutil"
+	"os"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"github.com/signintech/gopdf"
 )
 
+// 字体, 使用 FileName 或者 LoadFontBytes, LoadFontReader, LoadFontFS 创建
 type FontMap struct {
 	FontName string
 	FileName string
+	Style    string   // 字体文件的风格, "", "B", "I", "BI". 同一个 FontName 可以注册多个风格
+	Index    int      // 字体集合(.ttc)当中字体的序号, 从 0 开始
+	Fallback []string // 备用字体(FontName), 当前字体缺少字形时依次使用
+
+	data *fontData // 字体文件的内容和解析之后的字体数据, 可以共享
 }
 
 // 对接 pdf
@@ -24,6 +32,12 @@ type Converter struct {
 
 	linew    float64 // 线宽度(辅助)
 	lastFont string  // 最近字体(辅助)
+	font     Font    // 当前计算使用的字体(辅助)
+
+	glyphs map[string]bool // 字体是否包含字形(辅助), family|style|rune -> bool
+
+	images      map[string]gopdf.ImageHolder // 图片缓存(辅助), path|w|h -> ImageHolder
+	imagePolicy ImagePolicy                  // 图片嵌入策略
 }
 
 // var convert.unit float64 = 2.834645669
@@ -88,7 +102,7 @@ func (convert *Converter) Execute() {
 			convert.BackgroundColor(line, elements) // 背景颜色
 		case "GF", "GS":
 			convert.Grey(line, elements)
-		case "C", "CL", "CR":
+		case "C", "CL", "CR", "CT":
 			convert.Cell(line, elements) // 单元格内容
 		case "L", "LV", "LH", "LT":
 			convert.Line(line, elements) // 行
@@ -96,6 +110,8 @@ func (convert *Converter) Execute() {
 			convert.Rect(line, elements) // 长方形
 		case "O":
 			convert.Oval(line, elements) // 椭圆
+		case "PA":
+			convert.Path(line, elements) // 路径
 		case "I":
 			convert.Image(line, elements) // 图片
 		case "M":
@@ -106,6 +122,14 @@ func (convert *Converter) Execute() {
 			convert.InternalLinkAnchor(line, elements)
 		case "ILL":
 			convert.InternalLinkLink(line, elements)
+		case "LA":
+			convert.LinkArea(line, elements)
+		case "DS":
+			convert.Destination(line, elements)
+		case "CS":
+			convert.Clip(line, elements)
+		case "CE":
+			convert.ClipReset(line, elements)
 		default:
 			if len(line) > 0 && line[0:1] != "v" {
 				fmt.Println("skip:" + line + ":")
@@ -117,10 +141,19 @@ func (convert *Converter) Execute() {
 // 添加字体
 func (convert *Converter) AddFont() {
 	for _, font := range convert.fonts {
-		err := convert.pdf.AddTTFFont(font.FontName, font.FileName)
-		if err != nil {
+		parser, err := font.ttfParser()
+		if os.IsNotExist(err) {
 			panic("font file:" + font.FileName + " not found")
 		}
+		if err != nil {
+			panic("font " + font.FontName + ": " + err.Error())
+		}
+
+		option := gopdf.TtfOption{Style: fontStyle(font.Style)}
+		err = convert.pdf.AddTTFFontByParserWithOption(font.FontName, parser, option)
+		if err != nil {
+			panic("font " + font.FontName + ": " + err.Error())
+		}
 	}
 }
 
@@ -130,6 +163,7 @@ func (convert *Converter) AddFont() {
 // P|L 表示Portait, Landscape, 表示布局
 func (convert *Converter) Page(line string, elements []string) {
 	convert.pdf = new(gopdf.GoPdf)
+	convert.images = make(map[string]gopdf.ImageHolder)
 
 	checkLength(line, elements, 4)
 	switch elements[2] {
@@ -208,13 +242,15 @@ func (convert *Converter) Start(w float64, h float64) {
 
 // 设置当前文本使用的字体
 // ["", "family", "style", "size"]
-// style: "" or "U", ("B", "I")(需要字体本身支持)
+// style: "", "U", "B", "I" 的组合, 没有注册的 "B", "I" 使用合成的粗体, 斜体
 func (convert *Converter) Font(line string, elements []string) {
 	checkLength(line, elements, 4)
-	err := convert.pdf.SetFont(elements[1], elements[2], parseIntPanic(elements[3], line))
+	size := parseIntPanic(elements[3], line)
+	err := convert.pdf.SetFont(elements[1], elements[2], size)
 	if err != nil {
 		panic(err.Error() + " line;" + line)
 	}
+	convert.font = Font{Family: elements[1], Style: elements[2], Size: size}
 }
 
 // 设置笔画的灰度 | 设置填充的灰度
@@ -328,6 +364,69 @@ func (convert *Converter) Rect(line string, eles []string) {
 		parseFloatPanic(eles[4], line)*convert.unit+adj*2)
 }
 
+// 路径
+// ["PA", style, fill, stroke, w, path]
+// style: D(描边), F(填充), DF(填充并描边), 后缀 "*" 表示奇偶填充规则
+// fill, stroke: 填充颜色和线条颜色 "R,G,B", 空表示不设置
+// path: "M x y L x y C x1 y1 x2 y2 x3 y3 Z", 绝对坐标
+func (convert *Converter) Path(line string, elements []string) {
+	checkLength(line, elements, 6)
+
+	var (
+		ops    []gopdf.PathOp
+		tokens = strings.Fields(elements[5])
+	)
+	for i := 0; i < len(tokens); {
+		op := gopdf.PathOp{Op: tokens[i]}
+		i++
+
+		n := 0
+		switch op.Op {
+		case "M", "L":
+			n = 1
+		case "C":
+			n = 3
+		case "Z":
+		default:
+			panic("invalid path operator " + op.Op + " line;" + line)
+		}
+
+		for k := 0; k < n; k++ {
+			checkLength(line, tokens, i+2)
+			op.Points = append(op.Points, gopdf.Point{
+				X: parseFloatPanic(tokens[i], line) * convert.unit,
+				Y: parseFloatPanic(tokens[i+1], line) * convert.unit,
+			})
+			i += 2
+		}
+		ops = append(ops, op)
+	}
+
+	// 颜色和线宽只在当前路径有效, 之后恢复原来的状态
+	convert.pdf.SaveGraphicsState()
+	defer convert.pdf.RestoreGraphicsState()
+
+	if elements[2] != "" {
+		rgb := strings.Split(elements[2], ",")
+		checkLength(line, rgb, 3)
+		convert.pdf.SetFillColor(uint8(parseIntPanic(rgb[0], line)),
+			uint8(parseIntPanic(rgb[1], line)),
+			uint8(parseIntPanic(rgb[2], line)))
+	}
+	if elements[3] != "" {
+		rgb := strings.Split(elements[3], ",")
+		checkLength(line, rgb, 3)
+		convert.pdf.SetStrokeColor(uint8(parseIntPanic(rgb[0], line)),
+			uint8(parseIntPanic(rgb[1], line)),
+			uint8(parseIntPanic(rgb[2], line)))
+	}
+	if elements[4] != "" {
+		convert.pdf.SetLineWidth(parseFloatPanic(elements[4], line) * convert.unit)
+	}
+
+	convert.pdf.Path(ops, elements[1])
+}
+
 // 图片
 // ["I", path, x, y, x1, y2]
 func (convert *Converter) Image(line string, elements []string) {
@@ -336,12 +435,46 @@ func (convert *Converter) Image(line string, elements []string) {
 	r.W = parseFloatPanic(elements[4], line)*convert.unit - parseFloatPanic(elements[2], line)*convert.unit
 	r.H = parseFloatPanic(elements[5], line)*convert.unit - parseFloatPanic(elements[3], line)*convert.unit
 
-	convert.pdf.Image(
-		elements[1],
+	holder, err := convert.imageHolder(elements[1], r.W, r.H)
+	if err != nil {
+		panic(err.Error() + " line;" + line)
+	}
+
+	err = convert.pdf.ImageByHolder(
+		holder,
 		parseFloatPanic(elements[2], line)*convert.unit,
 		parseFloatPanic(elements[3], line)*convert.unit,
 		r,
 	)
+	if err != nil {
+		panic(err.Error() + " line;" + line)
+	}
+}
+
+// 获取图片的 ImageHolder, 每个路径(和尺寸)只读取和处理一次, 图片嵌入策略在这里执行.
+// ImageHolder 的 ID 是处理之后的图片内容的md5, 相同内容的图片(无论路径)只会嵌入一次, 所有页面共享同一个 XObject
+func (convert *Converter) imageHolder(path string, w, h float64) (gopdf.ImageHolder, error) {
+	key := path
+	if convert.imagePolicy.sized() {
+		key = path + "|" + strconv.FormatFloat(w, 'f', 2, 64) + "|" + strconv.FormatFloat(h, 'f', 2, 64)
+	}
+
+	if holder, ok := convert.images[key]; ok {
+		return holder, nil
+	}
+
+	buf, err := convert.imagePolicy.apply(path, w, h)
+	if err != nil {
+		return nil, err
+	}
+
+	holder, err := gopdf.ImageHolderByBytes(buf)
+	if err != nil {
+		return nil, err
+	}
+
+	convert.images[key] = holder
+	return holder, nil
 }
 
 // 线
@@ -391,39 +524,78 @@ func (convert *Converter) Line(line string, elements []string) {
 // ["C", family, size, x, y, content] // 从(x,y) 位置开始写入content
 // ["CL", x, y, content] // 从(x,y) 位置开始写入content
 // ["CR", x, y, w, content] // 从右往左写入w长度的内容
+// ["CT", x, y, content] // 以(x,y)为基线的开始位置写入content
 func (convert *Converter) Cell(line string, elements []string) {
 	switch elements[0] {
 	case "C":
 		checkLength(line, elements, 6)
-		err := convert.pdf.SetFont(elements[1], "", parseIntPanic(elements[2], line))
+		size := parseIntPanic(elements[2], line)
+		err := convert.pdf.SetFont(elements[1], "", size)
 		if err != nil {
 			panic(err.Error() + " line;" + line)
 		}
-		convert.setPosition(elements[3], elements[4], line)
-		convert.pdf.Cell(nil, elements[5])
+		convert.font = Font{Family: elements[1], Size: size}
+		x, y := convert.position(elements[3], elements[4], line)
+		convert.text(x, y, elements[5], true)
 	case "CL":
 		checkLength(line, elements, 4)
-		convert.setPosition(elements[1], elements[2], line)
-		convert.pdf.Cell(nil, elements[3])
+		x, y := convert.position(elements[1], elements[2], line)
+		convert.text(x, y, elements[3], true)
 	case "CR":
 		checkLength(line, elements, 5)
-		tw, err := convert.pdf.MeasureTextWidth(elements[4])
-		if err != nil {
-			panic(err.Error() + " line;" + line)
-		}
+		tw := convert.MeasureTextWidth(elements[4])
 		x := parseFloatPanic(elements[1], line) * convert.unit
 		y := parseFloatPanic(elements[2], line) * convert.unit
 		w := parseFloatPanic(elements[3], line) * convert.unit
-		finalx := x + w - tw
-		convert.pdf.SetX(finalx)
-		convert.pdf.SetY(y)
-		convert.pdf.Cell(nil, elements[4])
+		convert.text(x+w-tw, y, elements[4], true)
+	case "CT":
+		checkLength(line, elements, 4)
+		x, y := convert.position(elements[1], elements[2], line)
+		convert.text(x, y, elements[3], false)
 	}
 }
 
-func (convert *Converter) setPosition(x string, y string, line string) {
-	convert.pdf.SetX(parseFloatPanic(x, line) * convert.unit)
-	convert.pdf.SetY(parseFloatPanic(y, line) * convert.unit)
+func (convert *Converter) position(x string, y string, line string) (float64, float64) {
+	return parseFloatPanic(x, line) * convert.unit, parseFloatPanic(y, line) * convert.unit
+}
+
+// 写入文本, 缺少字形的字符使用备用字体. cell 为 true 时 y 是文本的顶部(Cell), 否则 y 是基线(Text)
+// 备用字体的文本和当前字体的文本使用相同的基线
+func (convert *Converter) text(x, y float64, text string, cell bool) {
+	runs := convert.fontRuns(text)
+	if len(runs) == 1 && runs[0].font == convert.font {
+		convert.pdf.SetX(x)
+		convert.pdf.SetY(y)
+		if cell {
+			convert.pdf.Cell(nil, text)
+		} else {
+			convert.pdf.Text(text)
+		}
+		return
+	}
+
+	current := convert.font
+	defer convert.restoreFont(current)
+
+	ascent, _, _ := convert.pdf.MeasureFontHeight()
+	for _, run := range runs {
+		convert.pdf.SetFont(run.font.Family, run.font.Style, run.font.Size)
+		width, err := convert.pdf.MeasureTextWidth(run.text)
+		if err != nil {
+			panic(err)
+		}
+
+		convert.pdf.SetX(x)
+		if cell {
+			runAscent, _, _ := convert.pdf.MeasureFontHeight()
+			convert.pdf.SetY(y + ascent - runAscent)
+			convert.pdf.Cell(nil, run.text)
+		} else {
+			convert.pdf.SetY(y)
+			convert.pdf.Text(run.text)
+		}
+		x += width
+	}
 }
 
 // 外部链接
@@ -431,8 +603,8 @@ func (convert *Converter) setPosition(x string, y string, line string) {
 func (convert *Converter) ExternalLink(line string, elements []string) {
 	checkLength(line, elements, 7)
 
-	x, y := parseFloatPanic(elements[1], line), parseFloatPanic(elements[2], line)
-	w, h := parseFloatPanic(elements[3], line), parseFloatPanic(elements[4], line)
+	x, y := parseFloatPanic(elements[1], line)*convert.unit, parseFloatPanic(elements[2], line)*convert.unit
+	w, h := parseFloatPanic(elements[3], line)*convert.unit, parseFloatPanic(elements[4], line)*convert.unit
 
 	convert.pdf.SetX(x)
 	convert.pdf.SetY(y)
@@ -442,19 +614,67 @@ func (convert *Converter) ExternalLink(line string, elements []string) {
 	if y-h > 0 {
 		y1 = y - h
 	}
-	convert.pdf.AddExternalLink(elements[6], x, y1, w, h)
+	convert.pdf.AddExternalLink(strings.Join(elements[6:], "|"), x, y1, w, h)
 
 	convert.pdf.SetX(x + w)
 	convert.pdf.SetY(y)
 }
 
+// 链接区域
+// ["LA", x, y, w, h, link] // (x,y)是区域的左上角
+// link: "#page=3" 链接到第3页, "#name" 链接到命名的目标, 其他的是外部链接
+func (convert *Converter) LinkArea(line string, elements []string) {
+	checkLength(line, elements, 6)
+
+	x, y := parseFloatPanic(elements[1], line)*convert.unit, parseFloatPanic(elements[2], line)*convert.unit
+	w, h := parseFloatPanic(elements[3], line)*convert.unit, parseFloatPanic(elements[4], line)*convert.unit
+	convert.addLink(strings.Join(elements[5:], "|"), x, y, w, h)
+}
+
+// 裁剪区域, 保存当前的状态
+// ["CS", x, y, w, h] // (x,y)是区域的左上角
+func (convert *Converter) Clip(line string, elements []string) {
+	checkLength(line, elements, 5)
+
+	convert.pdf.SaveGraphicsState()
+	convert.pdf.ClipRect(parseFloatPanic(elements[1], line)*convert.unit,
+		parseFloatPanic(elements[2], line)*convert.unit,
+		parseFloatPanic(elements[3], line)*convert.unit,
+		parseFloatPanic(elements[4], line)*convert.unit)
+}
+
+// 取消裁剪区域, 恢复之前的状态
+// ["CE"]
+func (convert *Converter) ClipReset(line string, elements []string) {
+	convert.pdf.RestoreGraphicsState()
+}
+
+func (convert *Converter) addLink(link string, x, y, w, h float64) {
+	switch {
+	case strings.HasPrefix(link, "#page="):
+		convert.pdf.AddPageLink(parseIntPanic(link[len("#page="):], link), x, y, w, h)
+	case strings.HasPrefix(link, "#"):
+		convert.pdf.AddInternalLink(link[1:], x, y, w, h)
+	default:
+		convert.pdf.AddExternalLink(link, x, y, w, h)
+	}
+}
+
+// 命名的目标(锚点), 位于当前页面的 y 处
+// ["DS", y, name]
+func (convert *Converter) Destination(line string, elements []string) {
+	checkLength(line, elements, 3)
+	y := parseFloatPanic(elements[1], line) * convert.unit
+	convert.pdf.SetAnchorWithPosition(strings.Join(elements[2:], "|"), y)
+}
+
 // 内部链接, 锚点
 // ["ILA", x, y, w, h, content, anchor]
 func (convert *Converter) InternalLinkAnchor(line string, elements []string) {
 	checkLength(line, elements, 7)
 
-	x, y := parseFloatPanic(elements[1], line), parseFloatPanic(elements[2], line)
-	w, h := parseFloatPanic(elements[3], line), parseFloatPanic(elements[4], line)
+	x, y := parseFloatPanic(elements[1], line)*convert.unit, parseFloatPanic(elements[2], line)*convert.unit
+	w, h := parseFloatPanic(elements[3], line)*convert.unit, parseFloatPanic(elements[4], line)*convert.unit
 	convert.pdf.SetX(x)
 	convert.pdf.SetY(y)
 
@@ -474,14 +694,15 @@ func (convert *Converter) InternalLinkAnchor(line string, elements []string) {
 func (convert *Converter) InternalLinkLink(line string, elements []string) {
 	checkLength(line, elements, 6)
 
-	convert.pdf.SetX(parseFloatPanic(elements[1], line))
-	convert.pdf.SetY(parseFloatPanic(elements[2], line))
+	x, y := parseFloatPanic(elements[1], line)*convert.unit, parseFloatPanic(elements[2], line)*convert.unit
+	convert.pdf.SetX(x)
+	convert.pdf.SetY(y)
 
 	convert.pdf.Text(elements[4])
 	convert.pdf.SetAnchor(elements[5])
 
-	convert.pdf.SetX(parseFloatPanic(elements[1], line) + parseFloatPanic(elements[3], line))
-	convert.pdf.SetY(parseFloatPanic(elements[2], line))
+	convert.pdf.SetX(x + parseFloatPanic(elements[3], line)*convert.unit)
+	convert.pdf.SetY(y)
 }
 
 // 辅助方法
@@ -502,17 +723,139 @@ func (convert *Converter) GetXY() (x, y float64) {
 	return convert.pdf.GetX(), convert.pdf.GetY()
 }
 
+// 计算文本的宽度, 缺少字形的字符使用备用字体计算
 func (convert *Converter) MeasureTextWidth(text string) float64 {
-	w, err := convert.pdf.MeasureTextWidth(text)
-	if err != nil {
-		panic(err)
+	runs := convert.fontRuns(text)
+	fallback := len(runs) > 1 || runs[0].font != convert.font
+	if fallback {
+		defer convert.restoreFont(convert.font)
+	}
+
+	var width float64
+	for _, run := range runs {
+		if fallback {
+			convert.pdf.SetFont(run.font.Family, run.font.Style, run.font.Size)
+		}
+		w, err := convert.pdf.MeasureTextWidth(run.text)
+		if err != nil {
+			panic(err)
+		}
+		width += w
+	}
+
+	return width
+}
+
+// 使用同一个字体的文本片段
+type fontRun struct {
+	font Font
+	text string
+}
+
+// 字体的备用字体
+func (convert *Converter) fallbacks(family string) []string {
+	for _, font := range convert.fonts {
+		if font.FontName == family && len(font.Fallback) > 0 {
+			return font.Fallback
+		}
 	}
+	return nil
+}
+
+// 按照字形拆分文本, 每个字符使用第一个包含该字形的字体(当前字体, 备用字体),
+// 空白字符跟随前一个字符的字体, 所有字体都没有的字形使用当前字体
+func (convert *Converter) fontRuns(text string) []fontRun {
+	font := convert.font
+	fallbacks := convert.fallbacks(font.Family)
+	if len(fallbacks) == 0 || text == "" {
+		return []fontRun{{font: font, text: text}}
+	}
+
+	defer convert.restoreFont(font)
+
+	var (
+		families = append([]string{font.Family}, fallbacks...)
+		runes    = []rune(text)
+		runs     []fontRun
+		current  = -1
+		start    = 0
+	)
+	for i, r := range runes {
+		index := current
+		if current == -1 || !unicode.IsSpace(r) {
+			index = convert.glyphFont(families, font, r)
+		}
+		if index == current {
+			continue
+		}
 
-	return w
+		if i > start {
+			runs = append(runs, fontRun{font: font, text: string(runes[start:i])})
+			runs[len(runs)-1].font.Family = families[current]
+		}
+		current, start = index, i
+	}
+	runs = append(runs, fontRun{font: font, text: string(runes[start:])})
+	runs[len(runs)-1].font.Family = families[current]
+
+	return runs
+}
+
+// 包含字形 r 的第一个字体的下标
+func (convert *Converter) glyphFont(families []string, font Font, r rune) int {
+	if convert.glyphs == nil {
+		convert.glyphs = make(map[string]bool)
+	}
+
+	style := strings.Replace(strings.ToUpper(font.Style), "U", "", -1)
+	for i, family := range families {
+		key := family + "|" + style + "|" + string(r)
+		has, ok := convert.glyphs[key]
+		if !ok {
+			has = convert.pdf.SetFont(family, style, font.Size) == nil && convert.pdf.HasGlyph(r)
+			convert.glyphs[key] = has
+		}
+		if has {
+			return i
+		}
+	}
+
+	return 0
 }
 
 func (convert *Converter) SetFont(family, style string, size int) {
 	convert.pdf.SetFont(family, style, size)
+	convert.font = Font{Family: family, Style: style, Size: size}
+}
+
+// 使用 font 计算文本的宽度, 不会改变当前的字体
+func (convert *Converter) MeasureTextWidthWithFont(font Font, text string) float64 {
+	current := convert.font
+	defer convert.restoreFont(current)
+
+	convert.SetFont(font.Family, font.Style, font.Size)
+	return convert.MeasureTextWidth(text)
+}
+
+// 字体的上升高度和下降高度(都是正数), 不会改变当前的字体
+func (convert *Converter) MeasureFontHeight(font Font) (ascent, descent float64) {
+	current := convert.font
+	defer convert.restoreFont(current)
+
+	convert.pdf.SetFont(font.Family, font.Style, font.Size)
+	ascender, descender, err := convert.pdf.MeasureFontHeight()
+	if err != nil {
+		panic(err)
+	}
+
+	return ascender, -descender
+}
+
+func (convert *Converter) restoreFont(font Font) {
+	convert.font = font
+	if font.Family != "" {
+		convert.pdf.SetFont(font.Family, font.Style, font.Size)
+	}
 }
 
 func (convert *Converter) NoCompression() {
@@ -0,0 +1,157 @@
+package core
+
+import (
+	"bytes"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/signintech/gopdf"
+	ttf "github.com/signintech/gopdf/fontmaker/core"
+)
+
+/**
+字体的加载.
+
+除了使用 FontMap.FileName 指定字体文件路径之外, 还可以从 []byte, io.Reader, fs.FS(embed.FS) 加载字体.
+解析之后的字体数据是只读的, 同一个 FontMap 可以在多个 Report 之间共享, 避免每个 Report 重复解析.
+使用 FileName 的字体按照文件的绝对路径缓存, 文件的修改时间或者大小变化的时候重新加载.
+
+支持 TrueType(.ttf), 字体集合(.ttc, 使用 FontMap.Index 选择字体) 和 CFF 轮廓的 OpenType(.otf).
+
+字体家族: 多个 FontName 相同, Style 不同的 FontMap. 缺少的风格使用已有的风格合成.
+**/
+
+var (
+	fontCache = map[string]*fontData{} // 字体文件的绝对路径 -> 字体数据, 每个路径只保留最新的数据
+	fontMutex sync.Mutex
+)
+
+// 字体文件的内容, 以及按照序号解析之后的字体
+type fontData struct {
+	mutex   sync.Mutex
+	data    []byte
+	parsers map[int]*ttf.TTFParser
+
+	modTime time.Time // 字体文件的修改时间和大小, 用于判断缓存是否失效
+	size    int64
+}
+
+// 从字体文件的内容加载字体, 字体集合(.ttc)使用 FontMap.Index 选择字体
+func LoadFontBytes(name string, data []byte) (*FontMap, error) {
+	font := &fontData{data: data, parsers: make(map[int]*ttf.TTFParser)}
+	if _, err := font.parser(0); err != nil {
+		return nil, err
+	}
+
+	return &FontMap{FontName: name, data: font}, nil
+}
+
+// 从 io.Reader 加载字体
+func LoadFontReader(name string, rd io.Reader) (*FontMap, error) {
+	data, err := ioutil.ReadAll(rd)
+	if err != nil {
+		return nil, err
+	}
+
+	return LoadFontBytes(name, data)
+}
+
+// 从 fs.FS 加载字体, 例如 //go:embed 的 embed.FS
+func LoadFontFS(name string, fsys fs.FS, path string) (*FontMap, error) {
+	data, err := fs.ReadFile(fsys, path)
+	if err != nil {
+		return nil, err
+	}
+
+	font, err := LoadFontBytes(name, data)
+	if err != nil {
+		return nil, err
+	}
+
+	font.FileName = path
+	return font, nil
+}
+
+// 字体家族, 粗体, 斜体, 粗斜体的字体文件可以为空
+func NewFontFamily(name, regular, bold, italic, boldItalic string) []*FontMap {
+	var (
+		fonts  []*FontMap
+		styles = []string{"", "B", "I", "BI"}
+	)
+	for i, path := range []string{regular, bold, italic, boldItalic} {
+		if path != "" {
+			fonts = append(fonts, &FontMap{FontName: name, FileName: path, Style: styles[i]})
+		}
+	}
+
+	return fonts
+}
+
+// 字体风格转换为 gopdf 的风格, 忽略下划线
+func fontStyle(style string) int {
+	style = strings.ToUpper(style)
+
+	result := gopdf.Regular
+	if strings.Contains(style, "B") {
+		result |= gopdf.Bold
+	}
+	if strings.Contains(style, "I") {
+		result |= gopdf.Italic
+	}
+	return result
+}
+
+// 解析之后的字体数据
+func (font *FontMap) ttfParser() (*ttf.TTFParser, error) {
+	if font.data != nil {
+		return font.data.parser(font.Index)
+	}
+
+	path, err := filepath.Abs(font.FileName)
+	if err != nil {
+		return nil, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	fontMutex.Lock()
+	data, ok := fontCache[path]
+	if !ok || !data.modTime.Equal(info.ModTime()) || data.size != info.Size() {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			fontMutex.Unlock()
+			return nil, err
+		}
+		data = &fontData{data: content, parsers: make(map[int]*ttf.TTFParser), modTime: info.ModTime(), size: info.Size()}
+		fontCache[path] = data
+	}
+	fontMutex.Unlock()
+
+	return data.parser(font.Index)
+}
+
+func (font *fontData) parser(index int) (*ttf.TTFParser, error) {
+	font.mutex.Lock()
+	defer font.mutex.Unlock()
+	if parser, ok := font.parsers[index]; ok {
+		return parser, nil
+	}
+
+	var parser ttf.TTFParser
+	parser.SetFaceIndex(index)
+	if err := parser.ParseByReader(bytes.NewReader(font.data)); err != nil {
+		return nil, err
+	}
+
+	font.parsers[index] = &parser
+	return &parser, nil
+}
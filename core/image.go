@@ -0,0 +1,139 @@
+package core
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+)
+
+// 图片嵌入策略, 在图片嵌入PDF的时候执行.
+// 除了 KeepOriginal 之外, PNG 图片重新编码为 JPEG(透明的部分使用白色背景填充)
+type ImagePolicy struct {
+	MaxDPI       float64 // 最大有效DPI, 超过时进行降采样. 0 表示不限制
+	JPEGQuality  int     // 重新编码的JPEG质量(1~100). 0 表示使用默认质量
+	KeepOriginal bool    // 保持原图, 不做降采样和重新编码
+}
+
+// 处理的结果是否和图片的尺寸有关
+func (policy ImagePolicy) sized() bool {
+	return !policy.KeepOriginal && policy.MaxDPI > 0
+}
+
+// 按照策略处理图片, w, h 是图片在PDF当中的尺寸(pt).
+// 返回处理之后的图片内容, 当不需要处理或者处理之后没有变小的时候, 返回原图内容
+func (policy ImagePolicy) apply(path string, w, h float64) ([]byte, error) {
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	isPNG := bytes.HasPrefix(buf, []byte("\x89PNG\r\n\x1a\n"))
+	if policy.KeepOriginal || !isPNG && policy.MaxDPI <= 0 && policy.JPEGQuality <= 0 {
+		return buf, nil
+	}
+
+	src, format, err := image.Decode(bytes.NewReader(buf))
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		bounds = src.Bounds()
+		dst    = src
+		resize bool
+	)
+
+	// 有效DPI = 像素 / 英寸
+	if policy.MaxDPI > 0 && w > 0 && h > 0 {
+		pw := int(math.Ceil(w * policy.MaxDPI / 72))
+		ph := int(math.Ceil(h * policy.MaxDPI / 72))
+		if pw < bounds.Dx() && ph < bounds.Dy() {
+			dst = resizeImage(src, pw, ph)
+			resize = true
+		}
+	}
+
+	if !resize && !isPNG && policy.JPEGQuality <= 0 {
+		return buf, nil
+	}
+
+	var options *jpeg.Options
+	if policy.JPEGQuality > 0 {
+		options = &jpeg.Options{Quality: policy.JPEGQuality}
+	}
+
+	var out bytes.Buffer
+	err = jpeg.Encode(&out, flattenImage(dst), options)
+	if err != nil {
+		return nil, err
+	}
+
+	// 没有降采样的JPEG, 重新编码之后变大, 保留原图
+	if !resize && format == "jpeg" && out.Len() >= len(buf) {
+		return buf, nil
+	}
+
+	return out.Bytes(), nil
+}
+
+// 透明的部分使用白色背景填充
+func flattenImage(src image.Image) image.Image {
+	if img, ok := src.(*image.YCbCr); ok {
+		return img
+	}
+	if img, ok := src.(*image.Gray); ok {
+		return img
+	}
+
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Bounds(), src, bounds.Min, draw.Over)
+	return dst
+}
+
+// 区域平均(box filter)降采样, 每个目标像素取其覆盖的源像素的平均值
+func resizeImage(src image.Image, w, h int) *image.RGBA {
+	var (
+		bounds = src.Bounds()
+		sw, sh = bounds.Dx(), bounds.Dy()
+		dst    = image.NewRGBA(image.Rect(0, 0, w, h))
+	)
+
+	for y := 0; y < h; y++ {
+		y0, y1 := y*sh/h, (y+1)*sh/h
+		if y1 == y0 {
+			y1 = y0 + 1
+		}
+
+		for x := 0; x < w; x++ {
+			x0, x1 := x*sw/w, (x+1)*sw/w
+			if x1 == x0 {
+				x1 = x0 + 1
+			}
+
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					cr, cg, cb, ca := src.At(bounds.Min.X+sx, bounds.Min.Y+sy).RGBA()
+					r, g, b, a = r+uint64(cr), g+uint64(cg), b+uint64(cb), a+uint64(ca)
+					n++
+				}
+			}
+
+			dst.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+
+	return dst
+}
@@ -113,6 +113,10 @@ func (report *Report) GetBytesPdf() (ret []byte) {
 
 	report.execute(true)
 	ret = report.converter.GetBytesPdf()
+
+	for i := range report.callbacks {
+		report.callbacks[i](report)
+	}
 	return
 }
 
@@ -247,6 +251,21 @@ func (report *Report) SetFonts(fmap []*FontMap) {
 	report.converter.fonts = fmap
 }
 
+// 设置图片嵌入策略(降采样, JPEG质量), 在图片嵌入PDF的时候执行
+func (report *Report) SetImagePolicy(policy ImagePolicy) {
+	report.converter.imagePolicy = policy
+}
+
+// 字体是否已经注册
+func (report *Report) HasFont(family string) bool {
+	for _, font := range report.converter.fonts {
+		if font.FontName == family {
+			return true
+		}
+	}
+	return false
+}
+
 // 获取当前页面编号
 func (report *Report) GetCurrentPageNo() int {
 	return report.pageNo
@@ -372,6 +391,16 @@ func (report *Report) MeasureTextWidth(text string) float64 {
 	return report.converter.MeasureTextWidth(text)
 }
 
+// 使用 font 计算文本宽度, 不会改变当前的字体
+func (report *Report) MeasureTextWidthWithFont(font Font, text string) float64 {
+	return report.converter.MeasureTextWidthWithFont(font, text)
+}
+
+// 字体的上升高度和下降高度(都是正数), 文本的基线在字体的上边界下方 ascent 的位置
+func (report *Report) MeasureFontHeight(font Font) (ascent, descent float64) {
+	return report.converter.MeasureFontHeight(font)
+}
+
 // 设置当前文本字体, 先注册,后设置
 func (report *Report) SetFontWithStyle(family, style string, size int) {
 	report.converter.SetFont(family, style, size)
@@ -415,6 +444,12 @@ func (report *Report) CellGray(x float64, y float64, content string, grayScale f
 	report.SetXY(report.converter.GetXY())
 }
 
+// 以(x,y)为基线的开始位置写入字符串内容
+func (report *Report) Text(x float64, y float64, content string) {
+	report.addAtomicCell("CT|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + content)
+	report.SetXY(report.converter.GetXY())
+}
+
 // 划线
 func (report *Report) LineType(ltype string, width float64) {
 	report.linew = width
@@ -443,6 +478,25 @@ func (report *Report) Oval(x1 float64, y1 float64, x2 float64, y2 float64) {
 		"|" + util.Ftoa(y2))
 }
 
+// 路径, path 是 "M x y L x y C x1 y1 x2 y2 x3 y3 Z" 格式的路径描述(绝对坐标)
+// style: "D" 描边, "F" 填充, "DF" 填充并描边, 后缀 "*" 表示使用奇偶填充规则
+// fillColor, strokeColor: 填充颜色和线条颜色, 格式是 "R,G,B"
+func (report *Report) Path(path string, style string, fillColor, strokeColor string, lineWidth float64) {
+	if fillColor != "" {
+		fillColor = util.CheckColor(fillColor)
+	}
+	if strokeColor != "" {
+		strokeColor = util.CheckColor(strokeColor)
+	}
+
+	var width string
+	if lineWidth > 0 {
+		width = util.Ftoa(lineWidth)
+	}
+
+	report.addAtomicCell("PA|" + style + "|" + fillColor + "|" + strokeColor + "|" + width + "|" + path)
+}
+
 // 设置当前的字体颜色, 线条颜色
 func (report *Report) TextDefaultColor() {
 	report.addAtomicCell("TC|" + strconv.Itoa(1) + "|" + strconv.Itoa(1) +
@@ -526,23 +580,38 @@ func (report *Report) Var(name string, val string) {
 	report.addAtomicCell("V|" + name + "|" + val)
 }
 
-// 外部链接
+// 外部链接, 以(x,y)为基线的开始位置写入 content, th 是链接区域的高度(基线以上)
 func (report *Report) ExternalLink(x, y, th float64, content, link string) {
 	tw := report.MeasureTextWidth(content)
-	if x+tw > report.config.endX {
-		tw = report.config.endX - x
-	}
 	report.addAtomicCell("EL|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + util.Ftoa(tw) + "|" + util.Ftoa(th) + "|" +
 		content + "|" + link)
 
 	report.SetXY(x+tw, y)
 }
 
+// 链接区域, (x, y) 是区域的左上角, 区域内没有内容.
+// link: "#page=3" 链接到第3页, "#name" 链接到 Destination 设置的目标, 其他的是外部链接
+func (report *Report) LinkArea(x, y, w, h float64, link string) {
+	report.addAtomicCell("LA|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + util.Ftoa(w) + "|" + util.Ftoa(h) + "|" + link)
+}
+
+// 裁剪区域, (x, y) 是区域的左上角. 之后写入的内容只显示区域之内的部分, 直到 ClipReset
+func (report *Report) Clip(x, y, w, h float64) {
+	report.addAtomicCell("CS|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + util.Ftoa(w) + "|" + util.Ftoa(h))
+}
+
+// 取消 Clip 设置的裁剪区域, 必须和 Clip 在同一页
+func (report *Report) ClipReset() {
+	report.addAtomicCell("CE")
+}
+
+// 在当前页面的 y 处设置命名的目标, LinkArea 使用 "#name" 链接到这个目标
+func (report *Report) Destination(name string, y float64) {
+	report.addAtomicCell("DS|" + util.Ftoa(y) + "|" + name)
+}
+
 func (report *Report) InternalLinkAnchor(x, y, th float64, content, anchor string) {
 	tw := report.MeasureTextWidth(content)
-	if x+tw > report.config.endX {
-		tw = report.config.endX - x
-	}
 
 	report.addAtomicCell("ILA|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + util.Ftoa(tw) + "|" + util.Ftoa(th) + "|" +
 		content + "|" + anchor)
@@ -552,9 +621,6 @@ func (report *Report) InternalLinkAnchor(x, y, th float64, content, anchor strin
 
 func (report *Report) InternalLinkLink(x, y float64, content, anchor string) {
 	tw := report.MeasureTextWidth(content)
-	if x+tw > report.config.endX {
-		tw = report.config.endX - x
-	}
 
 	report.addAtomicCell("ILL|" + util.Ftoa(x) + "|" + util.Ftoa(y) + "|" + util.Ftoa(tw) + "|" + content + "|" + anchor)
 
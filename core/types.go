@@ -37,8 +37,10 @@ func (scope *Scope) ReplaceMarign() {
 
 type Font struct {
 	Family string // 字体名称
-	Style  string // 字体风格, 目前支持, "" , "U", "B","I", 其中"B", "I" 需要字体本身定义
+	Style  string // 字体风格, 目前支持, "" , "U", "B","I", 其中"B", "I" 没有注册对应的字体文件时使用合成的粗体, 斜体
 	Size   int    // 字体大小
+
+	Language string // 语言, 用于断字, 需要先使用 LoadHyphenation 注册
 }
 
 type Cell interface {
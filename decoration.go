@@ -0,0 +1,100 @@
+package gopdf
+
+import (
+	"fmt"
+
+	"github.com/tiechui1994/gopdf/core"
+	"github.com/tiechui1994/gopdf/util"
+)
+
+const (
+	DECORATION_THICKNESS     = 0.05 // 线条默认的宽度, 字号的比例
+	DECORATION_UNDERLINE     = 0.12 // 下划线在基线之下的距离, 字号的比例
+	DECORATION_STRIKETHROUGH = 0.28 // 删除线在基线之上的距离, 字号的比例
+)
+
+// 文字装饰, 跟随每一行(每一段)文字绘制
+type TextDecoration struct {
+	Underline       bool    // 下划线
+	DoubleUnderline bool    // 双下划线
+	Strikethrough   bool    // 删除线
+	Color           string  // 线条颜色, 默认是黑色
+	Thickness       float64 // 线条宽度, 默认是字号的 DECORATION_THICKNESS
+	Highlight       string  // 高亮颜色, 文字的背景
+}
+
+func checkDecoration(decoration TextDecoration) {
+	if decoration.Color != "" {
+		util.CheckColor(decoration.Color)
+	}
+	if decoration.Highlight != "" {
+		util.CheckColor(decoration.Highlight)
+	}
+	if decoration.Thickness < 0 {
+		panic("invalid thickness")
+	}
+}
+
+// 高亮, 必须在写入文字之前绘制. (x, y) 是文字的左上角
+func (decoration TextDecoration) drawHighlight(pdf *core.Report, x, y, width float64, font core.Font) {
+	if decoration.Highlight == "" || width <= 0 {
+		return
+	}
+
+	ascent, descent := pdf.MeasureFontHeight(font)
+	pdf.BackgroundColor(x, y, width, ascent+descent, decoration.Highlight, "0000")
+}
+
+// 线条, 在写入文字之后绘制. (x, y) 是文字的左上角
+func (decoration TextDecoration) drawLines(pdf *core.Report, x, y, width float64, font core.Font) {
+	if !decoration.Underline && !decoration.DoubleUnderline && !decoration.Strikethrough || width <= 0 {
+		return
+	}
+
+	ascent, _ := pdf.MeasureFontHeight(font)
+	decoration.drawLinesAt(pdf, x, y+ascent, width, font.Size)
+}
+
+// 以基线为准绘制线条, 线条使用填充的矩形, 不影响当前的线条设置
+func (decoration TextDecoration) drawLinesAt(pdf *core.Report, x, baseline, width float64, size int) {
+	var (
+		thickness = decoration.Thickness
+		color     = decoration.Color
+		lines     []float64 // 线条中心的位置
+	)
+	if thickness == 0 {
+		thickness = float64(size) * DECORATION_THICKNESS
+	}
+	if color == "" {
+		color = "0,0,0"
+	}
+
+	underline := baseline + float64(size)*DECORATION_UNDERLINE
+	if decoration.Underline || decoration.DoubleUnderline {
+		lines = append(lines, underline)
+	}
+	if decoration.DoubleUnderline {
+		lines = append(lines, underline+thickness*2)
+	}
+	if decoration.Strikethrough {
+		lines = append(lines, baseline-float64(size)*DECORATION_STRIKETHROUGH)
+	}
+
+	for _, center := range lines {
+		top, bottom := center-thickness/2, center+thickness/2
+		path := fmt.Sprintf("M %s %s L %s %s L %s %s L %s %s Z", util.Ftoa(x), util.Ftoa(top),
+			util.Ftoa(x+width), util.Ftoa(top), util.Ftoa(x+width), util.Ftoa(bottom), util.Ftoa(x), util.Ftoa(bottom))
+		pdf.Path(path, "F", color, "", 0)
+	}
+}
+
+// 一行文字实际占用的宽度, 两端对齐(或者压缩)的行占满 width
+func lineExtent(pdf *core.Report, line string, width float64, kinsoku int, justify bool) float64 {
+	text := []rune(line)
+	lineWidth := measureRunes(pdf, text)
+	if _, count := lineGaps(text, lineWidth, width, kinsoku, justify); count > 0 {
+		return width
+	}
+
+	return lineWidth
+}
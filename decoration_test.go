@@ -0,0 +1,212 @@
+package gopdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const DECORATION_MD = "MPBOLD"
+
+func DecorationReport(fn func(report *core.Report)) *core.Report {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: DECORATION_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(fn), core.Detail)
+	return r
+}
+
+// 原子操作当中的线条: 顶部的 y 坐标, 宽度
+func decorationPaths(cells []string) (tops, widths []float64) {
+	for _, cell := range cells {
+		if !strings.HasPrefix(cell, "PA|F|") {
+			continue
+		}
+		path := strings.Fields(strings.Split(cell, "|")[5])
+		left, _ := strconv.ParseFloat(path[1], 64)
+		top, _ := strconv.ParseFloat(path[2], 64)
+		right, _ := strconv.ParseFloat(path[4], 64)
+		tops = append(tops, top)
+		widths = append(widths, right-left)
+	}
+	return tops, widths
+}
+
+func TestDecorationDiv(t *testing.T) {
+	var (
+		font    = core.Font{Family: DECORATION_MD, Size: 10}
+		div     *Div
+		ascent  float64
+		content = strings.Repeat("decorated text wraps across lines. ", 5)
+	)
+
+	r := DecorationReport(func(report *core.Report) {
+		ascent, _ = report.MeasureFontHeight(font)
+		div = NewDivWithWidth(200, 14, 2, report)
+		div.SetFont(font)
+		div.SetDecoration(TextDecoration{Underline: true, Strikethrough: true, Color: "255,0,0", Highlight: "255,255,0"})
+		div.SetContent(content)
+		div.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	var (
+		cells  = *r.GetAtomicCells()
+		lines  []float64 // 每一行文字的 y
+		lights int
+	)
+	for i, cell := range cells {
+		if strings.HasPrefix(cell, "BC|") {
+			lights++
+			// 高亮在文字之前
+			for _, next := range cells[i+1:] {
+				if strings.HasPrefix(next, "PA|") {
+					t.Fatalf("highlight after decoration lines")
+				}
+				if strings.HasPrefix(next, "CL|") {
+					break
+				}
+			}
+		}
+		if strings.HasPrefix(cell, "CL|") {
+			y, _ := strconv.ParseFloat(strings.Split(cell, "|")[2], 64)
+			lines = append(lines, y)
+		}
+	}
+
+	if len(lines) < 2 || lights != len(lines) {
+		t.Fatalf("expect highlight for every line, lines %v highlights %v", len(lines), lights)
+	}
+
+	tops, widths := decorationPaths(cells)
+	if len(tops) != 2*len(lines) {
+		t.Fatalf("expect %v lines, got %v", 2*len(lines), len(tops))
+	}
+
+	thickness := 10 * DECORATION_THICKNESS
+	for i, y := range lines {
+		baseline := y + ascent
+		underline := baseline + 10*DECORATION_UNDERLINE - thickness/2
+		strike := baseline - 10*DECORATION_STRIKETHROUGH - thickness/2
+		if math.Abs(tops[2*i]-underline) > 0.01 || math.Abs(tops[2*i+1]-strike) > 0.01 {
+			t.Errorf("line %v: expect %v %v, got %v %v", i, underline, strike, tops[2*i], tops[2*i+1])
+		}
+
+		width := r.MeasureTextWidthWithFont(font, div.contents[i])
+		if math.Abs(widths[2*i]-width) > 0.01 {
+			t.Errorf("line %v: expect width %v, got %v", i, width, widths[2*i])
+		}
+	}
+}
+
+func TestDecorationDouble(t *testing.T) {
+	r := DecorationReport(func(report *core.Report) {
+		cell := NewTextCell(200, 14, 2, report)
+		cell.SetFont(core.Font{Family: DECORATION_MD, Size: 10})
+		cell.SetDecoration(TextDecoration{DoubleUnderline: true, Thickness: 1})
+		cell.SetContent("double")
+		cell.GenerateAtomicCell(20)
+
+		span := NewSpan(14, 2, report)
+		span.SetFont(core.Font{Family: DECORATION_MD, Size: 10})
+		span.Copy("plain").GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	tops, _ := decorationPaths(*r.GetAtomicCells())
+	if len(tops) != 2 || math.Abs(tops[1]-tops[0]-2) > 0.01 {
+		t.Errorf("expect 2 underlines 2pt apart, got %v", tops)
+	}
+}
+
+func TestDecorationSpanWidth(t *testing.T) {
+	r := DecorationReport(func(report *core.Report) {
+		span := NewSpanWithWidth(6, 14, 2, report)
+		span.SetFont(core.Font{Family: DECORATION_MD, Size: 10})
+		span.SetDecoration(TextDecoration{Underline: true})
+		span.Copy("テスト").GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	// 字符比 span 宽, 装饰不超过内容的宽度
+	_, widths := decorationPaths(*r.GetAtomicCells())
+	if len(widths) == 0 {
+		t.Fatalf("underline is missing")
+	}
+	for _, width := range widths {
+		if width > 6+0.01 {
+			t.Errorf("underline is wider than the span: %v", width)
+		}
+	}
+}
+
+func TestDecorationRichText(t *testing.T) {
+	var (
+		md     = core.Font{Family: DECORATION_MD, Size: 10}
+		large  = core.Font{Family: DECORATION_MD, Size: 16}
+		ascent float64
+	)
+
+	r := DecorationReport(func(report *core.Report) {
+		ascent, _ = report.MeasureFontHeight(md)
+		rt := NewRichTextWithWidth(300, 2, report)
+		rt.SetContent(
+			TextRun{Text: "plain ", Font: md},
+			TextRun{Text: "struck", Font: md, Decoration: TextDecoration{Strikethrough: true}},
+			TextRun{Text: " large", Font: large, Decoration: TextDecoration{Underline: true, Highlight: "200,200,255"}},
+		)
+		rt.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	cells := *r.GetAtomicCells()
+	var (
+		y      float64
+		lights int
+	)
+	for _, cell := range cells {
+		if strings.HasPrefix(cell, "BC|") {
+			lights++
+		}
+		if strings.HasPrefix(cell, "CL|") && strings.HasSuffix(cell, "|plain ") {
+			y, _ = strconv.ParseFloat(strings.Split(cell, "|")[2], 64)
+		}
+	}
+	if lights == 0 {
+		t.Errorf("highlight is missing")
+	}
+
+	// 同一行使用相同的基线, 删除线按照所在片段的字号绘制
+	tops, _ := decorationPaths(cells)
+	if len(tops) != 2 {
+		t.Fatalf("expect 2 lines, got %v", len(tops))
+	}
+	baseline := y + ascent
+	strike := baseline - 10*DECORATION_STRIKETHROUGH - 10*DECORATION_THICKNESS/2
+	if math.Abs(tops[0]-strike) > 0.01 {
+		t.Errorf("expect strikethrough at %v, got %v", strike, tops[0])
+	}
+	underline := baseline + 16*DECORATION_UNDERLINE - 16*DECORATION_THICKNESS/2
+	if math.Abs(tops[1]-underline) > 0.01 {
+		t.Errorf("expect underline at %v, got %v", underline, tops[1])
+	}
+}
+
+func TestDecorationInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for negative thickness")
+		}
+	}()
+
+	r := DecorationReport(func(report *core.Report) {})
+	NewSpan(14, 2, r).SetDecoration(TextDecoration{Underline: true, Thickness: -1})
+}
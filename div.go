@@ -2,7 +2,6 @@ package gopdf
 
 import (
 	"math"
-	"strings"
 
 	"github.com/tiechui1994/gopdf/core"
 	"github.com/tiechui1994/gopdf/util"
@@ -21,6 +20,7 @@ type Div struct {
 	font      core.Font
 	frameType int // 边框类型, 默认是无边框
 	contents  []string
+	lastLines []bool // 段落的最后一行
 
 	width, height float64
 	lineHeight    float64
@@ -32,8 +32,14 @@ type Div struct {
 	margin core.Scope
 	border core.Scope
 
-	horizontalCentered bool // 水平居中
-	rightAlign         bool // 局右显示, 默认是居左显示
+	horizontalCentered bool   // 水平居中
+	rightAlign         bool   // 局右显示, 默认是居左显示
+	justify            bool   // 两端对齐
+	link               string // 链接, 每一行的内容都是链接区域
+	kinsoku            int    // 禁则处理方式
+	language           string // 断字的语言, 默认使用字体的语言
+	decoration         TextDecoration
+	text               string // 原始内容, 修改宽度之后重新分行
 }
 
 func NewDiv(lineHeight, lineSpce float64, pdf *core.Report) *Div {
@@ -87,6 +93,11 @@ func (div *Div) Copy(content string) *Div {
 		lineSpace:  div.lineSpace,
 		fontColor:  div.fontColor,
 		backColor:  div.backColor,
+		kinsoku:    div.kinsoku,
+		justify:    div.justify,
+		link:       div.link,
+		language:   div.language,
+		decoration: div.decoration,
 	}
 
 	f.SetMarign(div.margin)
@@ -178,11 +189,56 @@ func (div *Div) GetWidth() (width float64) {
 func (div *Div) HorizontalCentered() *Div {
 	div.horizontalCentered = true
 	div.rightAlign = false
+	div.justify = false
 	return div
 }
 func (div *Div) RightAlign() *Div {
 	div.rightAlign = true
 	div.horizontalCentered = false
+	div.justify = false
+	return div
+}
+
+// 两端对齐, 段落的最后一行居左
+func (div *Div) Justify() *Div {
+	div.justify = true
+	div.horizontalCentered = false
+	div.rightAlign = false
+	return div
+}
+
+// 设置链接, 链接区域跟随换行和分页. link 的格式参考 Report.LinkArea
+func (div *Div) SetLink(link string) *Div {
+	div.link = link
+	return div
+}
+
+// 设置文字装饰(下划线, 删除线, 高亮等), 每一行单独绘制
+func (div *Div) SetDecoration(decoration TextDecoration) *Div {
+	checkDecoration(decoration)
+	div.decoration = decoration
+	return div
+}
+
+// 设置禁则处理方式, 默认是 KINSOKU_PUSH_OUT, 必须在 SetContent 之前设置
+func (div *Div) SetKinsoku(kinsoku int) *Div {
+	checkKinsoku(kinsoku)
+	div.kinsoku = kinsoku
+
+	return div
+}
+
+// 断字器, 组件的语言优先
+func (div *Div) hyphenator() *Hyphenator {
+	if div.language != "" {
+		return getHyphenator(div.language)
+	}
+	return getHyphenator(div.font.Language)
+}
+
+// 设置断字的语言, 必须在 SetContent 之前设置
+func (div *Div) SetLanguage(language string) *Div {
+	div.language = language
 	return div
 }
 
@@ -212,13 +268,6 @@ func (div *Div) SetFontWithColor(font core.Font, color string) *Div {
 }
 
 func (div *Div) SetContent(content string) *Div {
-	convertStr := strings.Replace(content, "\t", "    ", -1)
-
-	var (
-		blocks       = strings.Split(convertStr, "\n") // 分行
-		contentWidth = div.width
-	)
-
 	// 必须检查字体
 	if util.IsEmpty(div.font) {
 		panic("there no avliable font")
@@ -227,43 +276,14 @@ func (div *Div) SetContent(content string) *Div {
 	// 必须先进行注册, 才能设置
 	div.pdf.Font(div.font.Family, div.font.Size, div.font.Style)
 	div.pdf.SetFontWithStyle(div.font.Family, div.font.Style, div.font.Size)
-	if len(blocks) == 1 {
-		if div.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			div.contents = []string{convertStr}
-			div.height = math.Abs(div.border.Top) + math.Abs(div.border.Bottom) + div.lineHeight
-			return div
-		}
-	}
-
-	for i := range blocks {
-		// 单独的一行
-		if div.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			div.contents = append(div.contents, blocks[i])
-			continue
-		}
 
-		var (
-			line []rune
-		)
-		// 单独的一行需要拆分
-		for _, r := range []rune(blocks[i]) {
-			line = append(line, r)
-			lineLength := div.pdf.MeasureTextWidth(string(line))
-			if lineLength >= contentWidth {
-				if lineLength-contentWidth > 2 {
-					div.contents = append(div.contents, string(line[0:len(line)-1]))
-					line = line[len(line)-1:]
-				} else {
-					div.contents = append(div.contents, string(line))
-					line = []rune{}
-				}
-			}
-		}
+	div.text = content
 
-		// 剩余单独成行
-		if len(line) > 0 {
-			div.contents = append(div.contents, string(line))
-		}
+	// 按照单词分行
+	div.contents, div.lastLines = lineBreaker{pdf: div.pdf, width: div.width, kinsoku: div.kinsoku, hyphenator: div.hyphenator()}.breakLines(content)
+	if len(div.contents) == 1 {
+		div.height = math.Abs(div.border.Top) + math.Abs(div.border.Bottom) + div.lineHeight
+		return div
 	}
 
 	// 重新计算 div 的高度
@@ -325,6 +345,7 @@ func (div *Div) GenerateAtomicCell() error {
 			div.margin = core.NewScope(div.margin.Left, 0, 0, 0)
 			div.border = core.NewScope(border.Left, div.lineHeight, border.Right, border.Bottom)
 			div.contents = div.contents[i:]
+			div.lastLines = div.lastLines[i:]
 			div.resetHeight()
 
 			newX, newY = div.pdf.GetPageStartXY()
@@ -338,11 +359,20 @@ func (div *Div) GenerateAtomicCell() error {
 		if !util.IsEmpty(div.fontColor) {
 			div.pdf.TextColor(util.GetColorRGB(div.fontColor))
 		}
+		justify := div.justify && !div.lastLines[i]
+		width := math.Min(lineExtent(div.pdf, div.contents[i], div.width, div.kinsoku, justify), div.width)
+		div.decoration.drawHighlight(div.pdf, x, y, width, div.font)
 		div.pdf.Font(div.font.Family, div.font.Size, div.font.Style) // 添加设置
-		div.pdf.Cell(x, y, div.contents[i])
+		writeLine(div.pdf, x, y, div.contents[i], div.width, div.kinsoku, justify)
 		if !util.IsEmpty(div.fontColor) {
 			div.pdf.TextDefaultColor()
 		}
+		div.decoration.drawLines(div.pdf, x, y, width, div.font)
+
+		// 链接区域
+		if div.link != "" && div.contents[i] != "" {
+			div.pdf.LinkArea(x, y, width, div.lineHeight, div.link)
+		}
 	}
 
 	x, _ = div.pdf.GetPageStartXY()
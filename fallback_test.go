@@ -0,0 +1,99 @@
+package gopdf
+
+import (
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const (
+	FALLBACK_MD = "ASCII"
+	FALLBACK_JP = "MPBOLD"
+)
+
+func FallbackReport(fallback bool, fn func(report *core.Report)) *core.Report {
+	// 主字体只有 ASCII 字符, 日文使用备用字体
+	mplus, _ := ioutil.ReadFile("example//ttf/mplus-1p-bold.ttf")
+	font, err := core.LoadFontBytes(FALLBACK_MD, buildASCII(mplus))
+	if err != nil {
+		panic(err)
+	}
+	if fallback {
+		font.Fallback = []string{FALLBACK_JP}
+	}
+	jp := core.FontMap{
+		FontName: FALLBACK_JP,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r := core.CreateReport()
+	r.SetFonts([]*core.FontMap{font, &jp})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(fn), core.Detail)
+	return r
+}
+
+func TestFontFallbackMeasure(t *testing.T) {
+	var (
+		text  = "ok テスト done"
+		plain float64
+		mixed float64
+		parts float64
+	)
+
+	FallbackReport(false, func(report *core.Report) {
+		report.SetFontWithStyle(FALLBACK_MD, "", 10)
+		plain = report.MeasureTextWidth(text)
+	}).GetBytesPdf()
+
+	FallbackReport(true, func(report *core.Report) {
+		report.SetFontWithStyle(FALLBACK_MD, "", 10)
+		mixed = report.MeasureTextWidth(text)
+
+		md := core.Font{Family: FALLBACK_MD, Size: 10}
+		jp := core.Font{Family: FALLBACK_JP, Size: 10}
+		parts = report.MeasureTextWidthWithFont(md, "ok ") +
+			report.MeasureTextWidthWithFont(jp, "テスト ") +
+			report.MeasureTextWidthWithFont(md, "done")
+
+		// 测量之后当前字体不变
+		if w := report.MeasureTextWidth("ok"); w != report.MeasureTextWidthWithFont(md, "ok") {
+			t.Errorf("current font changed: %v", w)
+		}
+	}).GetBytesPdf()
+
+	if math.Abs(mixed-parts) > 0.01 {
+		t.Errorf("expect width %v, got %v", parts, mixed)
+	}
+	if math.Abs(mixed-plain) < 0.01 {
+		t.Errorf("fallback is not used: %v", mixed)
+	}
+}
+
+func TestFontFallbackWrite(t *testing.T) {
+	content := strings.Repeat("check テスト mark ", 20)
+
+	var div *Div
+	r := FallbackReport(true, func(report *core.Report) {
+		div = NewDivWithWidth(150, 12, 2, report)
+		div.SetFont(core.Font{Family: FALLBACK_MD, Size: 10})
+		div.SetContent(content)
+		div.GenerateAtomicCell()
+	})
+	data := string(r.GetBytesPdf())
+
+	for _, line := range div.contents {
+		width := r.MeasureTextWidthWithFont(core.Font{Family: FALLBACK_MD, Size: 10}, line)
+		if width > 150 {
+			t.Errorf("line %q is wider than 150: %v", line, width)
+		}
+	}
+
+	// 两个字体都嵌入到文件当中
+	if strings.Count(data, "/FontFile2") != 2 {
+		t.Errorf("expect 2 embedded fonts, got %v", strings.Count(data, "/FontFile2"))
+	}
+}
@@ -0,0 +1,524 @@
+package gopdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"testing/fstest"
+
+	ttf "github.com/signintech/gopdf/fontmaker/core"
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const FONT_MD = "MPBOLD"
+
+func FontReport(font *core.FontMap) []byte {
+	return FontTextReport(font, "embedded font テスト")
+}
+
+func FontTextReport(font *core.FontMap, text string) []byte {
+	r := core.CreateReport()
+	r.SetFonts([]*core.FontMap{font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		div := NewDivWithWidth(200, 12, 2, report)
+		div.SetFont(core.Font{Family: FONT_MD, Size: 10})
+		div.SetContent(text)
+		div.GenerateAtomicCell()
+	}), core.Detail)
+
+	return r.GetBytesPdf()
+}
+
+func TestLoadFont(t *testing.T) {
+	data, err := ioutil.ReadFile("example/ttf/mplus-1p-bold.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fromBytes, err := core.LoadFontBytes(FONT_MD, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromReader, err := core.LoadFontReader(FONT_MD, bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fsys := fstest.MapFS{"ttf/mplus.ttf": &fstest.MapFile{Data: data}}
+	fromFS, err := core.LoadFontFS(FONT_MD, fsys, "ttf/mplus.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	fonts := map[string]*core.FontMap{
+		"bytes":  fromBytes,
+		"reader": fromReader,
+		"fs":     fromFS,
+		"file":   {FontName: FONT_MD, FileName: "example//ttf/mplus-1p-bold.ttf"},
+	}
+	for name, font := range fonts {
+		pdf := string(FontReport(font))
+		if strings.Count(pdf, "/FontFile2") != 1 {
+			t.Errorf("%v: font is not embedded", name)
+		}
+	}
+
+	if _, err = core.LoadFontBytes(FONT_MD, []byte("not a font")); err == nil {
+		t.Errorf("expect error for invalid font data")
+	}
+	if _, err = core.LoadFontFS(FONT_MD, fsys, "ttf/missing.ttf"); err == nil {
+		t.Errorf("expect error for missing file")
+	}
+}
+
+// 同一个 FontMap 在多个 Report 之间共享
+func TestLoadFontShared(t *testing.T) {
+	data, err := ioutil.ReadFile("example/ttf/mplus-1p-bold.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	font, err := core.LoadFontBytes(FONT_MD, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var (
+		wg      sync.WaitGroup
+		lengths = make([]int, 4)
+	)
+	for i := range lengths {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			lengths[i] = len(FontReport(font))
+		}(i)
+	}
+	wg.Wait()
+
+	for _, length := range lengths {
+		if length == 0 || length != lengths[0] {
+			t.Errorf("reports are different: %v", lengths)
+		}
+	}
+}
+
+// 字体文件修改之后重新加载
+func TestLoadFontModified(t *testing.T) {
+	data, err := ioutil.ReadFile("example/ttf/mplus-1p-bold.ttf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "font")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "font.ttf")
+	if err = ioutil.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if pdf := string(FontReport(&core.FontMap{FontName: FONT_MD, FileName: path})); strings.Count(pdf, "/FontFile2") != 1 {
+		t.Fatalf("font is not embedded")
+	}
+
+	if err = ioutil.WriteFile(path, []byte("not a font"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for the modified font file")
+		}
+	}()
+	FontReport(&core.FontMap{FontName: FONT_MD, FileName: path})
+}
+
+func FontFamilyReport(fonts []*core.FontMap, style string) (pdf, content string) {
+	r := core.CreateReport()
+	r.SetFonts(fonts)
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		div := NewDivWithWidth(200, 12, 2, report)
+		div.SetFont(core.Font{Family: "Body", Size: 10, Style: style})
+		div.SetContent("font family")
+		div.GenerateAtomicCell()
+	}), core.Detail)
+
+	data := r.GetBytesPdf()
+	return string(data), inflateStreams(data)
+}
+
+// 解压 PDF 文件当中的所有 stream
+func inflateStreams(data []byte) string {
+	var result bytes.Buffer
+	for _, match := range regexp.MustCompile(`(?s)stream\r?\n(.*?)endstream`).FindAllSubmatch(data, -1) {
+		rd, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			continue
+		}
+		content, _ := ioutil.ReadAll(rd)
+		result.Write(content)
+	}
+
+	return result.String()
+}
+
+func TestFontFamily(t *testing.T) {
+	var (
+		regular = "example//ttf/mplus-1p-bold.ttf"
+		bold    = "example//ttf/mplus-1p-bold.ttf"
+	)
+
+	cases := []struct {
+		fonts   []*core.FontMap
+		style   string
+		stroke  bool // 合成的粗体
+		oblique bool // 合成的斜体
+	}{
+		{core.NewFontFamily("Body", regular, "", "", ""), "", false, false},
+		{core.NewFontFamily("Body", regular, "", "", ""), "B", true, false},
+		{core.NewFontFamily("Body", regular, "", "", ""), "BI", true, true},
+		{core.NewFontFamily("Body", regular, bold, "", ""), "B", false, false},
+		{core.NewFontFamily("Body", regular, bold, "", ""), "BIU", false, true},
+		{core.NewFontFamily("Body", regular, "", regular, ""), "IU", false, false},
+	}
+
+	for i, c := range cases {
+		pdf, content := FontFamilyReport(c.fonts, c.style)
+		if !strings.Contains(content, "BT\n") {
+			t.Fatalf("case %v: text is not found", i)
+		}
+		if strings.Contains(content, " 2 Tr") != c.stroke {
+			t.Errorf("case %v: synthetic bold should be %v", i, c.stroke)
+		}
+		if strings.Contains(content, " Tm\n") != c.oblique {
+			t.Errorf("case %v: synthetic oblique should be %v", i, c.oblique)
+		}
+		if n := strings.Count(pdf, "/FontFile2"); n != len(c.fonts) {
+			t.Errorf("case %v: expect %v embedded fonts, got %v", i, len(c.fonts), n)
+		}
+	}
+}
+
+// 字体文件(sfnt)当中的表
+type sfntTable struct {
+	tag  string
+	data []byte
+}
+
+func readSfnt(data []byte) []sfntTable {
+	var tables []sfntTable
+	count := int(binary.BigEndian.Uint16(data[4:]))
+	for i := 0; i < count; i++ {
+		entry := data[12+16*i:]
+		offset := binary.BigEndian.Uint32(entry[8:])
+		length := binary.BigEndian.Uint32(entry[12:])
+		tables = append(tables, sfntTable{tag: string(entry[:4]), data: data[offset : offset+length]})
+	}
+	return tables
+}
+
+// 表目录和表的内容, 表的位置从 base 开始
+func writeSfnt(version string, tables []sfntTable, base int) (dir, body []byte) {
+	var dirBuff, bodyBuff bytes.Buffer
+	dirBuff.WriteString(version)
+	binary.Write(&dirBuff, binary.BigEndian, []uint16{uint16(len(tables)), 0, 0, 0})
+
+	base += 12 + 16*len(tables)
+	for _, table := range tables {
+		dirBuff.WriteString(table.tag)
+		binary.Write(&dirBuff, binary.BigEndian, []uint32{0, uint32(base + bodyBuff.Len()), uint32(len(table.data))})
+		bodyBuff.Write(table.data)
+		for bodyBuff.Len()%4 != 0 {
+			bodyBuff.WriteByte(0)
+		}
+	}
+	return dirBuff.Bytes(), bodyBuff.Bytes()
+}
+
+// 字体集合, 所有字体的表目录在前, 表的内容在后
+func buildCollection(fonts ...[]byte) []byte {
+	var (
+		header = 12 + 4*len(fonts)
+		dirs   [][]byte
+		bodies [][]byte
+		base   = header
+	)
+	for _, font := range fonts {
+		base += 12 + 16*len(readSfnt(font))
+	}
+	for _, font := range fonts {
+		// writeSfnt 认为表的内容紧跟在表目录之后
+		tables := readSfnt(font)
+		dir, body := writeSfnt("\x00\x01\x00\x00", tables, base-12-16*len(tables))
+		dirs = append(dirs, dir)
+		bodies = append(bodies, body)
+		base += len(body)
+	}
+
+	var buff bytes.Buffer
+	buff.WriteString("ttcf")
+	binary.Write(&buff, binary.BigEndian, []uint32{0x00010000, uint32(len(fonts))})
+	offset := header
+	for _, dir := range dirs {
+		binary.Write(&buff, binary.BigEndian, uint32(offset))
+		offset += len(dir)
+	}
+	for _, dir := range dirs {
+		buff.Write(dir)
+	}
+	for _, body := range bodies {
+		buff.Write(body)
+	}
+	return buff.Bytes()
+}
+
+func TestFontCollection(t *testing.T) {
+	mplus, _ := ioutil.ReadFile("example/ttf/mplus-1p-bold.ttf")
+	ascii, err := core.LoadFontBytes(FONT_MD, buildASCII(mplus))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir, err := ioutil.TempDir("", "ttc")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "test.ttc")
+	ioutil.WriteFile(path, buildCollection(mplus, buildASCII(mplus)), 0644)
+
+	width := func(font *core.FontMap) (width float64) {
+		r := core.CreateReport()
+		r.SetFonts([]*core.FontMap{font})
+		r.SetPage("A4", "P")
+		r.RegisterExecutor(core.Executor(func(report *core.Report) {
+			report.SetFontWithStyle(font.FontName, "", 10)
+			width = report.MeasureTextWidth("テスト")
+		}), core.Detail)
+		r.GetBytesPdf()
+		return width
+	}
+
+	expect := []float64{
+		width(&core.FontMap{FontName: FONT_MD, FileName: "example/ttf/mplus-1p-bold.ttf"}),
+		width(ascii),
+	}
+	if expect[0] == expect[1] {
+		t.Fatalf("faces have the same width: %v", expect[0])
+	}
+	for index := range expect {
+		if got := width(&core.FontMap{FontName: FONT_MD, FileName: path, Index: index}); got != expect[index] {
+			t.Errorf("face %v: expect width %v, got %v", index, expect[index], got)
+		}
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for face index out of range")
+		}
+	}()
+	width(&core.FontMap{FontName: FONT_MD, FileName: path, Index: 2})
+}
+
+// 测试使用的字形: 调用局部子程序, 然后画线
+var (
+	cffSubr  = []byte{0x8b, 0x8b, 0x15, 0xf7, 0x00, 0x06, 0x0b}
+	cffGlyph = []byte{0x20, 0x0a, 0xf7, 0x00, 0x07, 0x0e}
+)
+
+func cffIndex(items ...[]byte) []byte {
+	var buff bytes.Buffer
+	binary.Write(&buff, binary.BigEndian, uint16(len(items)))
+	if len(items) == 0 {
+		return buff.Bytes()
+	}
+
+	buff.WriteByte(4)
+	offset := uint32(1)
+	binary.Write(&buff, binary.BigEndian, offset)
+	for _, item := range items {
+		offset += uint32(len(item))
+		binary.Write(&buff, binary.BigEndian, offset)
+	}
+	for _, item := range items {
+		buff.Write(item)
+	}
+	return buff.Bytes()
+}
+
+func cffInt(v int) []byte {
+	return []byte{29, byte(v >> 24), byte(v >> 16), byte(v >> 8), byte(v)}
+}
+
+// CID-keyed 的 CFF 字体, 所有的字形都相同
+func buildCFF(numGlyphs int) []byte {
+	glyphs := make([][]byte, numGlyphs)
+	for i := range glyphs {
+		glyphs[i] = cffGlyph
+	}
+
+	var (
+		charset     = []byte{2, 0, 1, byte((numGlyphs - 2) >> 8), byte(numGlyphs - 2)}
+		fdSelect    = []byte{3, 0, 1, 0, 0, 0, byte(numGlyphs >> 8), byte(numGlyphs)}
+		charStrings = cffIndex(glyphs...)
+		private     = append(cffInt(6), 19)
+		subrs       = cffIndex(cffSubr)
+	)
+
+	top := func(offsets ...int) []byte {
+		dict := []byte{28, 1, 135, 28, 1, 136, 139, 12, 30} // ROS: Adobe Identity 0
+		dict = append(append(dict, cffInt(offsets[0])...), 15)
+		dict = append(append(dict, cffInt(offsets[1])...), 12, 37)
+		dict = append(append(dict, cffInt(offsets[2])...), 17)
+		dict = append(append(dict, cffInt(offsets[3])...), 12, 36)
+		return dict
+	}
+
+	var head bytes.Buffer
+	head.Write([]byte{1, 0, 4, 4})
+	head.Write(cffIndex([]byte("Test")))
+	start := head.Len() + len(cffIndex(top(0, 0, 0, 0)))
+	strs := cffIndex([]byte("Adobe"), []byte("Identity"))
+	start += len(strs) + len(cffIndex())
+
+	charsetAt := start
+	fdSelectAt := charsetAt + len(charset)
+	charStringsAt := fdSelectAt + len(fdSelect)
+	fdArrayAt := charStringsAt + len(charStrings)
+	fdArray := cffIndex(append(append(cffInt(len(private)), cffInt(0)...), 18))
+	privateAt := fdArrayAt + len(fdArray)
+	fdArray = cffIndex(append(append(cffInt(len(private)), cffInt(privateAt)...), 18))
+
+	head.Write(cffIndex(top(charsetAt, fdSelectAt, charStringsAt, fdArrayAt)))
+	head.Write(strs)
+	head.Write(cffIndex())
+	for _, part := range [][]byte{charset, fdSelect, charStrings, fdArray, private, subrs} {
+		head.Write(part)
+	}
+	return head.Bytes()
+}
+
+// 使用 TrueType 字体的度量信息, 把字形替换成 CFF
+func buildOpenType(ttf, cff []byte) []byte {
+	var tables []sfntTable
+	for _, table := range readSfnt(ttf) {
+		switch table.tag {
+		case "glyf", "loca", "cvt ", "fpgm", "prep":
+			continue
+		case "maxp":
+			table.data = append([]byte{0, 0, 0x50, 0}, table.data[4:6]...)
+		}
+		tables = append(tables, table)
+	}
+	tables = append(tables, sfntTable{tag: "CFF ", data: cff})
+
+	dir, body := writeSfnt("OTTO", tables, 0)
+	return append(dir, body...)
+}
+
+// 只保留 ASCII 字符的字体, 其他字符缺少字形
+func buildASCII(font []byte) []byte {
+	var parser ttf.TTFParser
+	parser.ParseByReader(bytes.NewReader(font))
+
+	// cmap format 4, 每个字符一段, 最后一段是 0xFFFF
+	var start, delta []uint16
+	for char := 0x20; char < 0x7f; char++ {
+		if glyph, ok := parser.Chars()[char]; ok {
+			start = append(start, uint16(char))
+			delta = append(delta, uint16(int(glyph)-char))
+		}
+	}
+	start = append(start, 0xFFFF)
+	delta = append(delta, 1)
+
+	var sub bytes.Buffer
+	count := len(start)
+	binary.Write(&sub, binary.BigEndian, []uint16{4, uint16(16 + 8*count), 0, uint16(2 * count), 0, 0, 0})
+	binary.Write(&sub, binary.BigEndian, start)
+	binary.Write(&sub, binary.BigEndian, uint16(0))
+	binary.Write(&sub, binary.BigEndian, start)
+	binary.Write(&sub, binary.BigEndian, delta)
+	binary.Write(&sub, binary.BigEndian, make([]uint16, count))
+
+	var cmap bytes.Buffer
+	binary.Write(&cmap, binary.BigEndian, []uint16{0, 1, 3, 1})
+	binary.Write(&cmap, binary.BigEndian, uint32(12))
+	cmap.Write(sub.Bytes())
+
+	var tables []sfntTable
+	for _, table := range readSfnt(font) {
+		if table.tag == "cmap" {
+			table.data = cmap.Bytes()
+		}
+		tables = append(tables, table)
+	}
+
+	dir, body := writeSfnt("\x00\x01\x00\x00", tables, 0)
+	return append(dir, body...)
+}
+
+// PDF 文件中嵌入的 CFF 字体
+func embeddedCFF(pdf []byte) []byte {
+	for _, match := range regexp.MustCompile(`(?s)/Subtype /CIDFontType0C\n>>\nstream\n(.*?)\nendstream`).FindAllSubmatch(pdf, -1) {
+		rd, err := zlib.NewReader(bytes.NewReader(match[1]))
+		if err != nil {
+			continue
+		}
+		data, _ := ioutil.ReadAll(rd)
+		return data
+	}
+	return nil
+}
+
+func TestOpenTypeCFF(t *testing.T) {
+	mplus, _ := ioutil.ReadFile("example/ttf/mplus-1p-bold.ttf")
+
+	var numGlyphs int
+	for _, table := range readSfnt(mplus) {
+		if table.tag == "maxp" {
+			numGlyphs = int(binary.BigEndian.Uint16(table.data[4:]))
+		}
+	}
+
+	cff := buildCFF(numGlyphs)
+	for round := 0; round < 2; round++ {
+		font, err := core.LoadFontBytes(FONT_MD, buildOpenType(mplus, cff))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		pdf := FontTextReport(font, "AB")
+		if !bytes.Contains(pdf, []byte("/Subtype /CIDFontType0\n")) || !bytes.Contains(pdf, []byte("/FontFile3 ")) {
+			t.Fatalf("round %v: CFF font is not embedded", round)
+		}
+
+		subset := embeddedCFF(pdf)
+		if round == 0 && (len(subset) == 0 || len(subset) >= len(cff)) {
+			t.Fatalf("expect subset smaller than %v, got %v", len(cff), len(subset))
+		}
+		if round == 1 && !bytes.Equal(subset, cff) {
+			t.Errorf("subset of subset is different")
+		}
+		// .notdef, A, B 三个字形
+		if n := bytes.Count(subset, cffGlyph); n != 3 {
+			t.Errorf("round %v: expect 3 glyphs, got %v", round, n)
+		}
+		if !bytes.Contains(subset, cffSubr) {
+			t.Errorf("round %v: local subrs are lost", round)
+		}
+
+		// 子集仍然是合法的 CFF, 可以再次嵌入
+		cff = subset
+	}
+}
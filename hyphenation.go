@@ -0,0 +1,244 @@
+package gopdf
+
+import (
+	"io/ioutil"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+/**
+断字(连字符), 基于 TeX 的断字模式(Liang 算法).
+
+模式文件可以使用 TeX 的格式(hyph-de-1996.tex 等, 包含 \patterns{...} 和 \hyphenation{...}),
+也可以是每行一个模式的纯文本(hyph-de-1996.pat.txt 等), 文件必须是 UTF-8 编码.
+**/
+
+const (
+	HYPHEN_MIN_LENGTH = 5 // 可以断字的单词的最小长度
+	HYPHEN_LEFT_MIN   = 2 // 断字之后, 行尾最少的字母个数
+	HYPHEN_RIGHT_MIN  = 3 // 断字之后, 行首最少的字母个数
+)
+
+// 断字器
+type Hyphenator struct {
+	patterns   map[string][]int
+	exceptions map[string][]int
+	maxLength  int // 模式的最大长度
+
+	Hyphen    string // 连字符, 默认是 "-"
+	MinLength int    // 单词的最小长度
+	LeftMin   int    // 断字位置之前最少的字母个数
+	RightMin  int    // 断字位置之后最少的字母个数
+}
+
+var (
+	hyphenators = map[string]*Hyphenator{}
+	hyphenMutex sync.RWMutex
+)
+
+// 加载 TeX 的断字模式文件, 并且注册为 lang 语言的断字器
+func LoadHyphenation(lang, path string) (*Hyphenator, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	hyphenator := NewHyphenator(string(data))
+	RegisterHyphenator(lang, hyphenator)
+
+	return hyphenator, nil
+}
+
+// 注册 lang 语言的断字器, Font.Language 或者组件的 SetLanguage 使用
+func RegisterHyphenator(lang string, hyphenator *Hyphenator) {
+	hyphenMutex.Lock()
+	defer hyphenMutex.Unlock()
+	hyphenators[strings.ToLower(lang)] = hyphenator
+}
+
+func getHyphenator(lang string) *Hyphenator {
+	if lang == "" {
+		return nil
+	}
+
+	hyphenMutex.RLock()
+	defer hyphenMutex.RUnlock()
+	return hyphenators[strings.ToLower(lang)]
+}
+
+// 解析断字模式
+func NewHyphenator(patterns string) *Hyphenator {
+	hyphenator := &Hyphenator{
+		patterns:   make(map[string][]int),
+		exceptions: make(map[string][]int),
+		Hyphen:     "-",
+		MinLength:  HYPHEN_MIN_LENGTH,
+		LeftMin:    HYPHEN_LEFT_MIN,
+		RightMin:   HYPHEN_RIGHT_MIN,
+	}
+
+	// 去掉注释
+	var lines []string
+	for _, line := range strings.Split(patterns, "\n") {
+		if index := strings.Index(line, "%"); index >= 0 {
+			line = line[:index]
+		}
+		lines = append(lines, line)
+	}
+	patterns = strings.Join(lines, "\n")
+
+	block, hasPatterns := texGroup(patterns, `\patterns`)
+	exceptions, _ := texGroup(patterns, `\hyphenation`)
+	if !hasPatterns {
+		block = patterns
+	}
+
+	for _, pattern := range strings.Fields(block) {
+		hyphenator.addPattern(pattern)
+	}
+	for _, exception := range strings.Fields(exceptions) {
+		hyphenator.addException(exception)
+	}
+
+	return hyphenator
+}
+
+// TeX 命令的参数 \command{...}
+func texGroup(content, command string) (string, bool) {
+	index := strings.Index(content, command)
+	if index < 0 {
+		return "", false
+	}
+
+	content = content[index+len(command):]
+	start := strings.Index(content, "{")
+	end := strings.Index(content, "}")
+	if start < 0 || end < start {
+		return "", false
+	}
+
+	return content[start+1 : end], true
+}
+
+// 模式 "a1b" 拆分成字母 "ab" 和权重 [0, 1, 0]
+func (h *Hyphenator) addPattern(pattern string) {
+	var (
+		letters []rune
+		values  = []int{0}
+	)
+
+	for _, r := range strings.ToLower(pattern) {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = int(r - '0')
+			continue
+		}
+		letters = append(letters, r)
+		values = append(values, 0)
+	}
+
+	if len(letters) == 0 {
+		return
+	}
+
+	h.patterns[string(letters)] = values
+	if len(letters) > h.maxLength {
+		h.maxLength = len(letters)
+	}
+}
+
+// 例外的单词 "ta-ble"
+func (h *Hyphenator) addException(exception string) {
+	var (
+		letters []rune
+		points  []int
+	)
+
+	for _, r := range strings.ToLower(exception) {
+		if r == '-' {
+			points = append(points, len(letters))
+			continue
+		}
+		letters = append(letters, r)
+	}
+
+	h.exceptions[string(letters)] = points
+}
+
+// 单词可以断字的位置, 位置 i 表示在 word[i] 之前
+func (h *Hyphenator) Hyphenate(word string) []int {
+	var (
+		letters = []rune(strings.ToLower(word))
+		length  = len(letters)
+		result  []int
+	)
+
+	if length < h.MinLength || length < h.LeftMin+h.RightMin {
+		return nil
+	}
+
+	valid := func(i int) bool {
+		return i >= h.LeftMin && length-i >= h.RightMin
+	}
+
+	if points, ok := h.exceptions[string(letters)]; ok {
+		for _, i := range points {
+			if valid(i) {
+				result = append(result, i)
+			}
+		}
+		return result
+	}
+
+	text := append(append([]rune{'.'}, letters...), '.')
+	points := make([]int, len(text)+1)
+	for i := 0; i < len(text); i++ {
+		for j := i + 1; j <= len(text) && j-i <= h.maxLength; j++ {
+			values, ok := h.patterns[string(text[i:j])]
+			if !ok {
+				continue
+			}
+			for k, value := range values {
+				if value > points[i+k] {
+					points[i+k] = value
+				}
+			}
+		}
+	}
+
+	// points[i+1] 对应 letters[i] 之前的位置
+	for i := 1; i < length; i++ {
+		if points[i+1]%2 == 1 && valid(i) {
+			result = append(result, i)
+		}
+	}
+
+	return result
+}
+
+// 片段中可以断字的位置, 只对片段中的单词部分(字母)断字
+func (h *Hyphenator) hyphenateSegment(segment []rune) []int {
+	var (
+		start = 0
+		end   = len(segment)
+	)
+
+	for start < end && !unicode.IsLetter(segment[start]) {
+		start++
+	}
+	for end > start && !unicode.IsLetter(segment[end-1]) {
+		end--
+	}
+	for i := start; i < end; i++ {
+		if !unicode.IsLetter(segment[i]) {
+			return nil
+		}
+	}
+
+	var points []int
+	for _, i := range h.Hyphenate(string(segment[start:end])) {
+		points = append(points, start+i)
+	}
+
+	return points
+}
@@ -0,0 +1,91 @@
+package gopdf
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const HYPHEN_MD = "MPBOLD"
+
+const hyphenPatterns = `% Liang 论文中的例子
+\patterns{
+hy3ph he2n hena4 hen5at 1na n2at 1tio 2io o2n
+}
+\hyphenation{
+ta-ble
+}`
+
+func HyphenationReport(language, content string) (lines []string) {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: HYPHEN_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		report.SetFontWithStyle(HYPHEN_MD, "", 10)
+		width := report.MeasureTextWidth("hyphenation")
+
+		cell := NewTextCell(width, 12, 2, report)
+		cell.SetFont(core.Font{Family: HYPHEN_MD, Size: 10, Language: language})
+		cell.SetContent(content)
+		lines = cell.contents
+	}), core.Detail)
+
+	r.Execute("hyphenation_test.pdf")
+	return lines
+}
+
+func TestHyphenate(t *testing.T) {
+	hyphenator := NewHyphenator(hyphenPatterns)
+
+	cases := map[string][]int{
+		"hyphenation": {2, 6},
+		"Hyphenation": {2, 6},
+		"table":       {2},
+		"on":          nil,
+	}
+	for word, expect := range cases {
+		if got := hyphenator.Hyphenate(word); !reflect.DeepEqual(got, expect) {
+			t.Errorf("%v: expect %v, got %v", word, expect, got)
+		}
+	}
+
+	hyphenator.LeftMin = 3
+	if got := hyphenator.Hyphenate("hyphenation"); !reflect.DeepEqual(got, []int{6}) {
+		t.Errorf("left min: got %v", got)
+	}
+}
+
+func TestHyphenationWrap(t *testing.T) {
+	dir, err := ioutil.TempDir("", "hyphen")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hyph-test.tex")
+	ioutil.WriteFile(path, []byte(hyphenPatterns), 0644)
+	if _, err = LoadHyphenation("test", path); err != nil {
+		t.Fatal(err)
+	}
+
+	content := "the hyphenation"
+	plain := HyphenationReport("", content)
+	if strings.Join(plain, "/") != "the/hyphenation" {
+		t.Errorf("without language: %q", plain)
+	}
+
+	lines := HyphenationReport("test", content)
+	if strings.Join(lines, "/") != "the hyphen-/ation" {
+		t.Errorf("with language: %q", lines)
+	}
+}
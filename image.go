@@ -1,6 +1,9 @@
 package gopdf
 
 import (
+	"fmt"
+	"image"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -8,48 +11,77 @@ import (
 	"github.com/tiechui1994/gopdf/core"
 )
 
+const (
+	IMAGE_FIT_CONTAIN = 1 // 等比缩放, 完整显示在区域内
+	IMAGE_FIT_COVER   = 2 // 等比缩放, 填满区域, 超出的部分裁剪
+	IMAGE_FIT_STRETCH = 3 // 拉伸, 填满区域
+	IMAGE_FIT_WIDTH   = 4 // 固定宽度, 高度等比缩放
+	IMAGE_FIT_HEIGHT  = 5 // 固定高度, 宽度等比缩放
+)
+
+// 图片没有分辨率信息时使用的默认DPI
+const IMAGE_DEFAULT_DPI = 72.0
+
 type Image struct {
 	pdf           *core.Report
 	path          string
 	width, height float64
 	margin        core.Scope
 	tempFilePath  string
+
+	horizontalCentered bool   // 水平居中
+	rightAlign         bool   // 水平居右, 默认是居左
+	link               string // 链接
 }
 
+// 根据图片自身的DPI计算尺寸, 超出内容区域的时候等比缩小
 func NewImage(path string, pdf *core.Report) *Image {
+	return NewImageWithDPI(path, 0, pdf)
+}
+
+// 根据指定的DPI计算尺寸, dpi<=0 时使用图片自身的DPI, 超出内容区域的时候等比缩小
+func NewImageWithDPI(path string, dpi float64, pdf *core.Report) *Image {
 	if _, err := os.Stat(path); err != nil {
 		panic("the path error")
 	}
 
-	var tempFilePath string
-	picturePath, _ := filepath.Abs(path)
-	imageType, _ := GetImageType(picturePath)
-	if imageType == "png" {
-		index := strings.LastIndex(picturePath, ".")
-		tempFilePath = picturePath[0:index] + ".jpeg"
-		err := ConvertPNG2JPEG(picturePath, tempFilePath)
-		if err != nil {
-			panic(err)
-		}
-		picturePath = tempFilePath
+	xdpi, ydpi := dpi, dpi
+	if dpi <= 0 {
+		xdpi, ydpi = GetImageDPI(path)
 	}
-
-	w, h := GetImageWidthAndHeight(picturePath)
-	image := &Image{
-		pdf:          pdf,
-		path:         picturePath,
-		width:        float64(w / 10),
-		height:       float64(h / 10),
-		tempFilePath: tempFilePath,
+	if xdpi <= 0 || ydpi <= 0 {
+		xdpi, ydpi = IMAGE_DEFAULT_DPI, IMAGE_DEFAULT_DPI
 	}
-	if tempFilePath != "" {
-		pdf.AddCallBack(image.delTempImage)
+
+	picturePath, _ := filepath.Abs(path)
+	w, h := imageSize(picturePath)
+	width, height := float64(w)*72/xdpi, float64(h)*72/ydpi
+
+	contentWidth, contentHeight := pdf.GetContentWidthAndHeight()
+	if width > contentWidth || height > contentHeight {
+		scale := math.Min(contentWidth/width, contentHeight/height)
+		width, height = width*scale, height*scale
 	}
 
-	return image
+	return newImage(picturePath, "", width, height, pdf)
 }
 
+// 在 width*height 的区域内等比缩放
 func NewImageWithWidthAndHeight(path string, width, height float64, pdf *core.Report) *Image {
+	return NewImageWithFit(path, width, height, IMAGE_FIT_CONTAIN, pdf)
+}
+
+// 按照 fit 模式在 width*height 的区域内放置图片
+// IMAGE_FIT_WIDTH 忽略 height, IMAGE_FIT_HEIGHT 忽略 width
+func NewImageWithFit(path string, width, height float64, fit int, pdf *core.Report) *Image {
+	switch {
+	case fit < IMAGE_FIT_CONTAIN || fit > IMAGE_FIT_HEIGHT:
+		panic("invalid image fit")
+	case fit != IMAGE_FIT_HEIGHT && !(width > 0),
+		fit != IMAGE_FIT_WIDTH && !(height > 0):
+		panic("the image width and height must be greater than 0")
+	}
+
 	contentWidth, contentHeight := pdf.GetContentWidthAndHeight()
 	if width > contentWidth {
 		width = contentWidth
@@ -62,26 +94,36 @@ func NewImageWithWidthAndHeight(path string, width, height float64, pdf *core.Re
 		panic("the path error")
 	}
 
-	var tempFilePath string
-	picturePath, _ := filepath.Abs(path)
-	imageType, _ := GetImageType(picturePath)
-
-	if imageType == "png" {
-		index := strings.LastIndex(picturePath, ".")
-		tempFilePath = picturePath[0:index] + ".jpeg"
-		err := ConvertPNG2JPEG(picturePath, tempFilePath)
-		if err != nil {
-			panic(err.Error())
-		}
-		picturePath = tempFilePath
+	var (
+		picturePath, tempFilePath string
+	)
+
+	switch fit {
+	case IMAGE_FIT_COVER:
+		picturePath, tempFilePath = coverImage(path, width, height)
+	default:
+		picturePath, _ = filepath.Abs(path)
 	}
 
-	w, h := GetImageWidthAndHeight(picturePath)
-	if float64(h)*width/float64(w) > height {
-		width = float64(w) * height / float64(h)
-	} else {
+	w, h := imageSize(picturePath)
+	switch fit {
+	case IMAGE_FIT_COVER, IMAGE_FIT_STRETCH:
+	case IMAGE_FIT_WIDTH:
 		height = float64(h) * width / float64(w)
+	case IMAGE_FIT_HEIGHT:
+		width = float64(w) * height / float64(h)
+	default:
+		if float64(h)*width/float64(w) > height {
+			width = float64(w) * height / float64(h)
+		} else {
+			height = float64(h) * width / float64(w)
+		}
 	}
+
+	return newImage(picturePath, tempFilePath, width, height, pdf)
+}
+
+func newImage(picturePath, tempFilePath string, width, height float64, pdf *core.Report) *Image {
 	image := &Image{
 		pdf:          pdf,
 		path:         picturePath,
@@ -97,12 +139,67 @@ func NewImageWithWidthAndHeight(path string, width, height float64, pdf *core.Re
 	return image
 }
 
+// 按照 width:height 的比例从图片中心裁剪, 返回裁剪后的临时文件路径
+func coverImage(path string, width, height float64) (picturePath, tempFilePath string) {
+	picturePath, _ = filepath.Abs(path)
+	w, h := imageSize(picturePath)
+
+	// 裁剪区域至少保留 1 像素
+	cw, ch := w, h
+	if float64(w)*height > float64(h)*width {
+		cw = int(math.Max(float64(h)*width/height, 1))
+	} else {
+		ch = int(math.Max(float64(w)*height/width, 1))
+	}
+
+	if cw == w && ch == h {
+		return picturePath, ""
+	}
+
+	// 裁剪之后使用无损的 PNG 保存, 是否重新编码由图片嵌入策略决定
+	x, y := (w-cw)/2, (h-ch)/2
+	index := strings.LastIndex(picturePath, ".")
+	tempFilePath = fmt.Sprintf("%v_%vx%v.png", picturePath[0:index], cw, ch)
+	err := CropImage(picturePath, tempFilePath, image.Rect(x, y, x+cw, y+ch))
+	if err != nil {
+		panic(err)
+	}
+
+	return tempFilePath, tempFilePath
+}
+
+// 图片的像素尺寸, 宽度或者高度为 0 的图片无法计算缩放比例
+func imageSize(path string) (w, h int) {
+	w, h = GetImageWidthAndHeight(path)
+	if w <= 0 || h <= 0 {
+		panic("the image width and height must be greater than 0")
+	}
+	return w, h
+}
+
 func (image *Image) SetMargin(margin core.Scope) *Image {
 	margin.ReplaceMarign()
 	image.margin = margin
 	return image
 }
 
+func (image *Image) HorizontalCentered() *Image {
+	image.horizontalCentered = true
+	image.rightAlign = false
+	return image
+}
+func (image *Image) RightAlign() *Image {
+	image.rightAlign = true
+	image.horizontalCentered = false
+	return image
+}
+
+// 设置链接, 整个图片是链接区域. link 的格式参考 Report.LinkArea
+func (image *Image) SetLink(link string) *Image {
+	image.link = link
+	return image
+}
+
 func (image *Image) GetHeight() float64 {
 	return image.height
 }
@@ -117,12 +214,25 @@ func (image *Image) GenerateAtomicCell() error {
 	)
 
 	x, y := sx+image.margin.Left, sy+image.margin.Top
-	_, pageEndY := image.pdf.GetPageEndXY()
+	pageEndX, pageEndY := image.pdf.GetPageEndXY()
 	if y < pageEndY && y+float64(image.height) > pageEndY {
 		image.pdf.AddNewPage(false)
+		sx, sy = image.pdf.GetXY()
+		x, y = sx+image.margin.Left, sy+image.margin.Top
+	}
+
+	// 在内容区域的水平对齐
+	if image.horizontalCentered && x+image.width < pageEndX {
+		x += (pageEndX - x - image.width) / 2
+	}
+	if image.rightAlign && x+image.width < pageEndX {
+		x = pageEndX - image.width
 	}
 
 	image.pdf.Image(image.path, x, y, x+float64(image.width), y+float64(image.height))
+	if image.link != "" {
+		image.pdf.LinkArea(x, y, image.width, image.height, image.link)
+	}
 	sx, _ = image.pdf.GetPageStartXY()
 	image.pdf.SetXY(sx, y+float64(image.height)+image.margin.Bottom)
 	return nil
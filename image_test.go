@@ -1,6 +1,16 @@
 package gopdf
 
 import (
+	"bytes"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 
 	"github.com/tiechui1994/gopdf/core"
@@ -57,3 +67,244 @@ func ImageReportExecutor(report *core.Report) {
 func TestImage(t *testing.T) {
 	ComplexImageReport()
 }
+
+func ImageFitReport() *core.Report {
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(ImageFitReportExecutor), core.Detail)
+	r.Execute("image_fit_test.pdf")
+
+	return r
+}
+func ImageFitReportExecutor(report *core.Report) {
+	cat := "example//pictures/cat.jpg"
+
+	NewImageWithFit(cat, 200, 50, IMAGE_FIT_CONTAIN, report).HorizontalCentered().GenerateAtomicCell()
+	NewImageWithFit(cat, 200, 50, IMAGE_FIT_COVER, report).GenerateAtomicCell()
+	NewImageWithFit(cat, 200, 50, IMAGE_FIT_STRETCH, report).RightAlign().GenerateAtomicCell()
+	NewImageWithFit(cat, 200, 50, IMAGE_FIT_WIDTH, report).GenerateAtomicCell()
+	NewImageWithFit(cat, 200, 50, IMAGE_FIT_HEIGHT, report).GenerateAtomicCell()
+	NewImageWithDPI(cat, 300, report).GenerateAtomicCell()
+}
+
+func TestImageFit(t *testing.T) {
+	r := ImageFitReport()
+
+	pw, ph := GetImageWidthAndHeight("example//pictures/cat.jpg")
+	ratio := float64(pw) / float64(ph)
+	sx, _ := r.GetPageStartXY()
+	ex, _ := r.GetPageEndXY()
+
+	var images [][]float64
+	for _, cell := range *r.GetAtomicCells() {
+		if !strings.HasPrefix(cell, "I|") {
+			continue
+		}
+
+		var rect []float64
+		for _, v := range strings.Split(cell, "|")[2:] {
+			f, _ := strconv.ParseFloat(v, 64)
+			rect = append(rect, f)
+		}
+		images = append(images, rect)
+	}
+
+	if len(images) != 6 {
+		t.Fatalf("expect 6 images, got %v", len(images))
+	}
+
+	size := func(i int) (float64, float64) {
+		return images[i][2] - images[i][0], images[i][3] - images[i][1]
+	}
+	near := func(a, b float64) bool {
+		return math.Abs(a-b) < 0.1
+	}
+
+	if w, h := size(0); !near(h, 50) || !near(w, 50*ratio) || !near(images[0][0]-sx, ex-images[0][2]) {
+		t.Errorf("contain: %v", images[0])
+	}
+	if w, h := size(1); !near(w, 200) || !near(h, 50) {
+		t.Errorf("cover: %v", images[1])
+	}
+	if w, h := size(2); !near(w, 200) || !near(h, 50) || !near(images[2][2], ex) {
+		t.Errorf("stretch: %v", images[2])
+	}
+	if w, h := size(3); !near(w, 200) || !near(h, 200/ratio) {
+		t.Errorf("width: %v", images[3])
+	}
+	if w, h := size(4); !near(w, 50*ratio) || !near(h, 50) {
+		t.Errorf("height: %v", images[4])
+	}
+	if w, h := size(5); !near(w, math.Min(float64(pw)*72/300, 415)) || !near(w/h, ratio) {
+		t.Errorf("dpi: %v", images[5])
+	}
+}
+
+func TestImageFitInvalid(t *testing.T) {
+	cases := []struct {
+		width, height float64
+		fit           int
+	}{
+		{0, 50, IMAGE_FIT_CONTAIN},
+		{50, 0, IMAGE_FIT_COVER},
+		{math.NaN(), 50, IMAGE_FIT_STRETCH},
+		{0, 50, IMAGE_FIT_WIDTH},
+		{50, 0, IMAGE_FIT_HEIGHT},
+		{50, 50, 0},
+	}
+
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+	for i, c := range cases {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("case %v: expect panic", i)
+				}
+			}()
+			NewImageWithFit("example//pictures/cat.jpg", c.width, c.height, c.fit, r)
+		}()
+	}
+
+	// 很窄的区域, 裁剪之后至少保留 1 像素
+	image := NewImageWithFit("example//pictures/cat.jpg", 400, 0.01, IMAGE_FIT_COVER, r)
+	image.delTempImage(r)
+}
+
+// 换页之后保留上边距
+func TestImageMarginNewPage(t *testing.T) {
+	var startY float64
+
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		_, startY = report.GetPageStartXY()
+		_, endY := report.GetPageEndXY()
+		x, _ := report.GetXY()
+		report.SetXY(x, endY-20)
+
+		NewImageWithWidthAndHeight("example//pictures/cat.jpg", 100, 50, report).
+			SetMargin(core.Scope{Top: 10}).GenerateAtomicCell()
+	}), core.Detail)
+	r.GetBytesPdf()
+
+	var found bool
+	for _, cell := range *r.GetAtomicCells() {
+		if strings.HasPrefix(cell, "I|") {
+			y, _ := strconv.ParseFloat(strings.Split(cell, "|")[3], 64)
+			if math.Abs(y-startY-10) > 0.01 {
+				t.Errorf("expect image at %v, got %v", startY+10, y)
+			}
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("image is missing")
+	}
+}
+
+func ImageDedupReport(pages int, paths ...string) []byte {
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+	r.FisrtPageNeedHeader = true
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		for _, path := range paths {
+			NewImageWithWidthAndHeight(path, 100, 30, report).GenerateAtomicCell()
+		}
+	}), core.Header)
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		for i := 1; i < pages; i++ {
+			report.AddNewPage(false)
+		}
+	}), core.Detail)
+
+	return r.GetBytesPdf()
+}
+
+func TestImageDedup(t *testing.T) {
+	path := "example//pictures/random.png"
+	one, many := len(ImageDedupReport(1, path)), len(ImageDedupReport(50, path))
+
+	// 每页只增加页面对象和图片引用, 图片本身只嵌入一次
+	if perPage := (many - one) / 49; perPage > 1024 {
+		t.Errorf("output grows %v bytes per page (1 page: %v, 50 pages: %v)", perPage, one, many)
+	}
+
+	// 相同内容的图片, 路径不同, 也只嵌入一次
+	buf, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir, err := ioutil.TempDir("", "gopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	copied := filepath.Join(dir, "copy.png")
+	if err = ioutil.WriteFile(copied, buf, 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if two := len(ImageDedupReport(1, path, copied)); two-one > 1024 {
+		t.Errorf("the same image from two paths embedded twice (one: %v, two: %v)", one, two)
+	}
+}
+
+func ImagePolicyReport(path string, policy core.ImagePolicy) []byte {
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+	r.SetImagePolicy(policy)
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		NewImageWithWidthAndHeight(path, 100, 100, report).GenerateAtomicCell()
+	}), core.Detail)
+
+	return r.GetBytesPdf()
+}
+
+func TestImagePolicy(t *testing.T) {
+	path := "example//pictures/example.png"
+	original := len(ImagePolicyReport(path, core.ImagePolicy{KeepOriginal: true, MaxDPI: 72}))
+	downsampled := len(ImagePolicyReport(path, core.ImagePolicy{MaxDPI: 72}))
+	quality := len(ImagePolicyReport(path, core.ImagePolicy{MaxDPI: 72, JPEGQuality: 30}))
+
+	if downsampled >= original || quality >= downsampled {
+		t.Errorf("original: %v, downsampled: %v, quality: %v", original, downsampled, quality)
+	}
+}
+
+// PNG 图片只在嵌入的时候按照策略处理: KeepOriginal 保留 PNG, 否则重新编码为 JPEG, 透明的部分是白色
+func TestImagePolicyPNG(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gopdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "transparent.png")
+	fd, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	png.Encode(fd, image.NewNRGBA(image.Rect(0, 0, 20, 20)))
+	fd.Close()
+
+	if pdf := ImagePolicyReport(path, core.ImagePolicy{KeepOriginal: true}); bytes.Contains(pdf, []byte("/DCTDecode")) {
+		t.Errorf("expect the original png embedded")
+	}
+
+	pdf := ImagePolicyReport(path, core.ImagePolicy{})
+	start := bytes.Index(pdf, []byte{0xFF, 0xD8})
+	if !bytes.Contains(pdf, []byte("/DCTDecode")) || start < 0 {
+		t.Fatalf("expect the png embedded as jpeg")
+	}
+	img, err := jpeg.Decode(bytes.NewReader(pdf[start:]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r, g, b, _ := img.At(10, 10).RGBA(); r>>8 < 250 || g>>8 < 250 || b>>8 < 250 {
+		t.Errorf("expect white background, got %v %v %v", r>>8, g>>8, b>>8)
+	}
+}
@@ -0,0 +1,137 @@
+package gopdf
+
+import (
+	"strconv"
+)
+
+/**
+Table的分页控制.
+
+	KeepTogether  行不拆分, 当前页面写不下的时候整行移到下一页
+	KeepWithNext  行和下一行写在同一页, 用于分组的标题行. 多个连续的行可以串联
+	MinSplitLines 拆分的单元格在分页之前(孤行)和分页之后(寡行)至少保留的行数, 不满足的时候减少当前页面
+	              写入的行数, 仍然不满足的时候该单元格整体移到下一页
+
+行数是单元格元素 TryGenerateAtomicCell 返回的行数. 页面的顶部无法满足的时候(行的高度超过一页),
+忽略以上的设置, 在页面的底部拆分.
+**/
+
+// 设置不拆分的行
+func (table *Table) SetKeepTogether(rows ...int) {
+	for _, row := range rows {
+		table.checkKeepRow(row)
+		if table.keepTogether == nil {
+			table.keepTogether = make(map[int]bool)
+		}
+		table.keepTogether[row] = true
+	}
+}
+
+// 设置和下一行写在同一页的行
+func (table *Table) SetKeepWithNext(rows ...int) {
+	for _, row := range rows {
+		table.checkKeepRow(row)
+		if table.keepWithNext == nil {
+			table.keepWithNext = make(map[int]bool)
+		}
+		table.keepWithNext[row] = true
+	}
+}
+
+// 设置拆分的单元格在分页之前和分页之后至少保留的行数
+func (table *Table) SetMinSplitLines(before, after int) {
+	if before < 0 || after < 0 {
+		panic("invalid min split lines")
+	}
+	table.minLinesBefore, table.minLinesAfter = before, after
+}
+
+func (table *Table) checkKeepRow(row int) {
+	if row < 0 || row >= table.rows {
+		panic("invalid row: " + strconv.Itoa(row))
+	}
+}
+
+// 在 height 的高度内拆分 cells 所在的行, 返回拆分的高度, 0 表示不能拆分. widow 表示需要满足最少的行数
+func (table *Table) splitHeight(cells []*TableCell, height float64, widow bool) float64 {
+	for height > 0 {
+		var (
+			writing bool
+			limit   = -1 // 不满足行数的单元格最多可以写入的行数
+			target  *TableCell
+		)
+		for _, cell := range cells {
+			if table.cellHeight(cell) == 0 {
+				continue
+			}
+
+			writed, remain := cell.element.TryGenerateAtomicCell(height)
+			if writed == 0 {
+				continue
+			}
+
+			lines := writed
+			if widow && remain > 0 && remain < table.minLinesAfter {
+				lines = writed + remain - table.minLinesAfter
+			}
+			if widow && remain > 0 && lines < table.minLinesBefore || lines < 0 {
+				lines = 0
+			}
+			if lines < writed {
+				limit, target = lines, cell
+				break
+			}
+			writing = true
+		}
+
+		if target == nil {
+			if writing {
+				return height
+			}
+			return 0
+		}
+		height = table.maxSplitHeight(target, limit, height)
+	}
+	return 0
+}
+
+// 单元格写入的行数不超过 lines 的最大高度(小于 height)
+func (table *Table) maxSplitHeight(cell *TableCell, lines int, height float64) float64 {
+	low, high := 0.0, height
+	for high-low > 0.001 {
+		mid := (low + high) / 2
+		if writed, _ := cell.element.TryGenerateAtomicCell(mid); writed > lines {
+			high = mid
+		} else {
+			low = mid
+		}
+	}
+	return low
+}
+
+// 分页处的行和下一行需要写在同一页的时候, 提前分页. 提前之后没有任何行的时候, 整体移到下一页,
+// 页面的顶部(force)或者从上一页延续的行保持不变
+func (table *Table) keepRows(layout tableLayout, force bool) tableLayout {
+	if layout.split || layout.end >= table.end {
+		return layout
+	}
+
+	end := layout.end
+	for end > layout.start && table.keepWithNext[end-1] {
+		end--
+	}
+	switch {
+	case end == layout.end:
+		return layout
+	case end == layout.start && (force || layout.continued):
+		return layout
+	case end == layout.start:
+		return tableLayout{start: layout.start, end: layout.start, bottom: layout.tops[0], continued: layout.continued}
+	}
+
+	n := end - layout.start
+	layout.end = end
+	layout.tops, layout.heights = layout.tops[:n], layout.heights[:n]
+	layout.bottom = layout.tops[n-1] + layout.heights[n-1]
+	return layout
+}
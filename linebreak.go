@@ -0,0 +1,481 @@
+package gopdf
+
+import (
+	"math"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+/**
+文本分行, 基于 Unicode 换行算法(UAX #14)的简化实现.
+
+	1. 先计算每个字符之前是否允许换行(换行机会), 英文等在空格之后换行, 中文, 日文等表意文字可以在任意字符之间换行,
+	   标点符号遵循 "不能在行首" 和 "不能在行尾" 的规则.
+	2. 然后按照换行机会把文本拆分成不可分割的片段, 贪心的方式把片段放入行内.
+	3. 当某个片段(比如很长的单词, URL)超过了整行的宽度, 这个片段按照字符拆分.
+	4. 禁则处理: 行首禁则字符(，。）」等)和前面的字符一起移到下一行(追い出し), 或者悬挂在行尾(ぶら下げ),
+	   或者压缩当前行的字符间距挤进当前行(追い込み). 行尾禁则字符(（「【等)总是和后面的字符在同一行.
+	5. 设置了断字器时, 放不下的单词按照断字模式拆分, 单词的前半部分加上连字符留在当前行.
+**/
+
+// 禁则处理方式
+const (
+	KINSOKU_PUSH_OUT = 1 // 追い出し, 禁则字符和前面的字符一起移到下一行, 默认
+	KINSOKU_HANGING  = 2 // ぶら下げ, 句读点悬挂在行尾, 可以超出内容的宽度
+	KINSOKU_PUSH_IN  = 3 // 追い込み, 禁则字符挤进当前行, 压缩当前行的字符间距
+)
+
+func checkKinsoku(kinsoku int) {
+	if kinsoku < KINSOKU_PUSH_OUT || kinsoku > KINSOKU_PUSH_IN {
+		panic("invalid kinsoku")
+	}
+}
+
+// 可以悬挂在行尾的句读点
+var kinsokuHanging = map[rune]bool{
+	'、': true, '。': true, '，': true, '．': true, '｡': true, '､': true, ',': true, '.': true,
+}
+
+// 换行类别(UAX #14 的子集)
+const (
+	lbAL = iota // 字母, 默认
+	lbNU        // 数字
+	lbID        // 表意文字(中文, 日文, 韩文), 前后都可以换行
+	lbSP        // 空格
+	lbBA        // 之后可以换行(连字符, 竖线)
+	lbB2        // 前后都可以换行(破折号)
+	lbHY        // 连字符 "-"
+	lbOP        // 开始标点, 之后不能换行
+	lbCL        // 结束标点, 之前不能换行
+	lbQU        // 引号
+	lbEX        // 感叹号, 问号
+	lbIS        // 数字分隔符 , . : ;
+	lbSY        // 斜线
+	lbNS        // 不能在行首的字符(小写假名, 长音符等)
+	lbIN        // 省略号
+	lbPR        // 前缀(货币符号)
+	lbPO        // 后缀(百分号)
+	lbGL        // 不换行的字符(不换行空格)
+	lbWJ        // 单词连接符
+	lbZW        // 零宽空格
+	lbCM        // 组合字符
+)
+
+// 特殊字符的换行类别
+var lineBreakClasses = map[rune]int{
+	' ': lbSP, '\t': lbBA, '|': lbBA, '-': lbHY, '/': lbSY,
+	'!': lbEX, '?': lbEX, ',': lbIS, '.': lbIS, ':': lbIS, ';': lbIS,
+	'"': lbQU, '\'': lbQU, '$': lbPR, '+': lbPR, '\\': lbPR, '%': lbPO,
+
+	'\u00A0': lbGL, '\u2007': lbGL, '\u202F': lbGL, '\u2060': lbWJ, '\uFEFF': lbWJ,
+	'\u200B': lbZW, '\u200D': lbCM, '\u00AD': lbBA, '‐': lbBA, '–': lbBA,
+	'—': lbB2, '‥': lbIN, '…': lbIN, '°': lbPO, '¢': lbPO,
+	'‰': lbPO, '‱': lbPO, '′': lbPO, '″': lbPO, '£': lbPR,
+	'¥': lbPR, '€': lbPR, '\u3000': lbBA,
+
+	// 中日文标点
+	'、': lbCL, '。': lbCL, '，': lbCL, '．': lbCL, '｡': lbCL, '､': lbCL,
+	'：': lbNS, '；': lbNS, '！': lbEX, '？': lbEX, '・': lbNS, '･': lbNS,
+	'ー': lbNS, '々': lbNS, '〻': lbNS, 'ゝ': lbNS, 'ゞ': lbNS, 'ヽ': lbNS, 'ヾ': lbNS,
+	'ぁ': lbNS, 'ぃ': lbNS, 'ぅ': lbNS, 'ぇ': lbNS, 'ぉ': lbNS, 'っ': lbNS, 'ゃ': lbNS,
+	'ゅ': lbNS, 'ょ': lbNS, 'ゎ': lbNS, 'ゕ': lbNS, 'ゖ': lbNS, 'ァ': lbNS, 'ィ': lbNS,
+	'ゥ': lbNS, 'ェ': lbNS, 'ォ': lbNS, 'ッ': lbNS, 'ャ': lbNS, 'ュ': lbNS, 'ョ': lbNS,
+	'ヮ': lbNS, 'ヵ': lbNS, 'ヶ': lbNS, '〜': lbNS, '～': lbNS, 'ㇰ': lbNS, 'ㇱ': lbNS,
+	'ㇲ': lbNS, 'ㇳ': lbNS, 'ㇴ': lbNS, 'ㇵ': lbNS, 'ㇶ': lbNS, 'ㇷ': lbNS, 'ㇸ': lbNS,
+	'ㇹ': lbNS, 'ㇺ': lbNS, 'ㇻ': lbNS, 'ㇼ': lbNS, 'ㇽ': lbNS, 'ㇾ': lbNS, 'ㇿ': lbNS,
+	'゛': lbNS, '゜': lbNS, '゠': lbNS, '‼': lbNS, '⁇': lbNS, '⁈': lbNS, '⁉': lbNS,
+	'〃': lbNS, 'ヿ': lbNS, '％': lbPO, '＄': lbPR, '￥': lbPR, '￡': lbPR,
+}
+
+// 字符的换行类别
+func lineBreakClass(r rune) int {
+	if class, ok := lineBreakClasses[r]; ok {
+		return class
+	}
+
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me):
+		return lbCM
+	case unicode.Is(unicode.Ps, r):
+		return lbOP
+	case unicode.Is(unicode.Pe, r):
+		return lbCL
+	case unicode.In(r, unicode.Pi, unicode.Pf):
+		return lbQU
+	case r >= '0' && r <= '9', unicode.Is(unicode.Nd, r) && r < 0x3000:
+		return lbNU
+	case unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Hangul, unicode.Bopomofo):
+		return lbID
+	case r >= 0x3000 && r <= 0x303F, r >= 0xFF01 && r <= 0xFF60, r >= 0xFFE0 && r <= 0xFFE6:
+		return lbID // 中日文标点符号, 全角字符
+	case r >= 0x1F300 && r <= 0x1FAFF, r >= 0x2600 && r <= 0x27BF:
+		return lbID // emoji
+	}
+
+	return lbAL
+}
+
+// 计算换行机会, breaks[i] 表示在 text[i] 之前是否可以换行
+func lineBreakOpportunities(text []rune) []bool {
+	var (
+		breaks  = make([]bool, len(text))
+		classes = make([]int, len(text))
+	)
+
+	for i, r := range text {
+		classes[i] = lineBreakClass(r)
+
+		// 组合字符跟随前面的字符(LB9)
+		if classes[i] == lbCM && i > 0 && classes[i-1] != lbSP {
+			classes[i] = classes[i-1]
+			continue
+		}
+		if classes[i] == lbCM {
+			classes[i] = lbAL
+		}
+	}
+
+	for i := 1; i < len(text); i++ {
+		a, b := classes[i-1], classes[i]
+		if lineBreakClass(text[i]) == lbCM {
+			continue
+		}
+
+		// 空格之前的字符类别(LB14 ~ LB17)
+		before := a
+		for k := i - 1; k > 0 && classes[k] == lbSP; k-- {
+			before = classes[k-1]
+		}
+
+		switch {
+		case b == lbSP || b == lbZW: // LB7
+		case a == lbZW: // LB8
+			breaks[i] = true
+		case a == lbWJ || b == lbWJ: // LB11
+		case a == lbGL: // LB12
+		case b == lbGL && a != lbSP && a != lbBA && a != lbHY: // LB12a
+		case b == lbCL || b == lbEX || b == lbIS || b == lbSY: // LB13
+		case before == lbOP: // LB14
+		case (before == lbCL) && b == lbNS: // LB16
+		case before == lbB2 && b == lbB2: // LB17
+		case a == lbSP: // LB18
+			breaks[i] = true
+		case a == lbQU || b == lbQU: // LB19
+		case b == lbBA || b == lbHY || b == lbNS: // LB21
+		case b == lbIN: // LB22
+		case a == lbAL && b == lbNU, a == lbNU && b == lbAL: // LB23
+		case a == lbPR && b == lbID, (a == lbPR || a == lbPO) && b == lbAL, a == lbAL && (b == lbPR || b == lbPO): // LB24
+		case (a == lbCL || a == lbNU) && (b == lbPO || b == lbPR), (a == lbPO || a == lbPR) && (b == lbOP || b == lbNU),
+			(a == lbHY || a == lbIS || a == lbNU || a == lbSY) && b == lbNU, a == lbOP && b == lbNU: // LB25
+		case a == lbAL && b == lbAL: // LB28
+		case a == lbIS && b == lbAL: // LB29
+		case (a == lbAL || a == lbNU) && b == lbOP && text[i] < 0x2E80, a == lbCL && (b == lbAL || b == lbNU) && text[i-1] < 0x2E80: // LB30
+		default: // LB31
+			breaks[i] = true
+		}
+	}
+
+	return breaks
+}
+
+// 分行
+type lineBreaker struct {
+	pdf     *core.Report
+	width   float64
+	kinsoku int // 禁则处理方式
+
+	hyphenator *Hyphenator // 断字器, nil 表示不断字
+
+	// text[start:end] 的宽度, hyphen 表示末尾添加连字符. nil 表示使用当前的字体计算
+	measure func(text []rune, start, end int, hyphen bool) float64
+}
+
+// 一行的内容是 text[start:end], hyphen 表示行尾需要添加连字符
+type lineRange struct {
+	start, end int
+	hyphen     bool
+}
+
+// 按照 width 对内容进行分行("\n"强制换行), 必须先设置字体. lastLines 标记段落的最后一行
+func (lb lineBreaker) breakLines(content string) (lines []string, lastLines []bool) {
+	content = strings.Replace(content, "\t", "    ", -1)
+	content = strings.Replace(content, "\r", "", -1)
+	for _, paragraph := range strings.Split(content, "\n") {
+		text := []rune(paragraph)
+		block := lb.breakParagraph(text)
+		for i, line := range block {
+			content := string(text[line.start:line.end])
+			if line.hyphen {
+				content += lb.hyphenator.Hyphen
+			}
+			lines = append(lines, content)
+			lastLines = append(lastLines, i == len(block)-1)
+		}
+	}
+
+	return lines, lastLines
+}
+
+// 单独的一段进行分行, 空的段落是一个空行
+func (lb lineBreaker) breakParagraph(text []rune) []lineRange {
+	var (
+		width = lb.width
+	)
+
+	if len(text) == 0 || lb.measureRange(text, 0, len(text), false) <= width {
+		return []lineRange{{start: 0, end: len(text)}}
+	}
+
+	var (
+		breaks = lineBreakOpportunities(text)
+		lines  []lineRange
+		ls, le int // 当前行 text[ls:le], 包括末尾空格
+		start  int
+	)
+
+	for i := 1; i <= len(text); i++ {
+		if i < len(text) && !breaks[i] {
+			continue
+		}
+
+		// 不可分割的片段 text[ss:se], 末尾的空格在行尾可以忽略
+		ss, se := start, i
+		start = i
+		for ss < se {
+			tail := trimRightSpace(text, ss, se)
+			if lb.measureRange(text, ls, tail, false) <= width {
+				le = se
+				break
+			}
+
+			// 行尾的禁则字符悬挂或者挤进当前行
+			if n := lb.hanging(text[ss:tail]); n > 0 && lb.measureRange(text, ls, tail-n, false) <= width {
+				lines = append(lines, lineRange{start: ls, end: tail})
+				ls, le = se, se
+				break
+			}
+
+			// 断字, 单词的前半部分和连字符留在当前行
+			if n := lb.hyphenate(text, ls, ss, se); n > 0 {
+				lines = append(lines, lineRange{start: ls, end: n, hyphen: true})
+				ls, le, ss = n, n, n
+				continue
+			}
+
+			if le > ls {
+				lines = append(lines, lineRange{start: ls, end: trimRightSpace(text, ls, le)})
+				ls, le = ss, ss
+				continue
+			}
+
+			// 片段超过整行的宽度, 按照字符拆分
+			n := lb.fitRunes(text, ss, se)
+			lines = append(lines, lineRange{start: ss, end: n})
+			ls, le, ss = n, n, n
+		}
+	}
+
+	if le > ls {
+		lines = append(lines, lineRange{start: ls, end: trimRightSpace(text, ls, le)})
+	}
+
+	return lines
+}
+
+// 内容的最小宽度(最宽的不可分割的片段)和最大宽度(最宽的段落, 不换行), 必须先设置字体
+func (lb lineBreaker) measureContent(content string) (min, max float64) {
+	content = strings.Replace(content, "\t", "    ", -1)
+	content = strings.Replace(content, "\r", "", -1)
+	for _, paragraph := range strings.Split(content, "\n") {
+		text := []rune(paragraph)
+		if len(text) == 0 {
+			continue
+		}
+
+		max = math.Max(max, lb.measureRange(text, 0, trimRightSpace(text, 0, len(text)), false))
+
+		breaks := lineBreakOpportunities(text)
+		start := 0
+		for i := 1; i <= len(text); i++ {
+			if i < len(text) && !breaks[i] {
+				continue
+			}
+			if end := trimRightSpace(text, start, i); end > start {
+				min = math.Max(min, lb.measureRange(text, start, end, false))
+			}
+			start = i
+		}
+	}
+
+	return min, max
+}
+
+func (lb lineBreaker) measureRange(text []rune, start, end int, hyphen bool) float64 {
+	if lb.measure != nil {
+		return lb.measure(text, start, end, hyphen)
+	}
+
+	content := string(text[start:end])
+	if hyphen {
+		content += lb.hyphenator.Hyphen
+	}
+	if content == "" {
+		return 0
+	}
+	return lb.pdf.MeasureTextWidth(content)
+}
+
+// 片段末尾可以超出行宽的字符个数
+func (lb lineBreaker) hanging(segment []rune) int {
+	var n int
+	for k := len(segment) - 1; k > 0 && n < 2; k-- {
+		r := segment[k]
+		switch lb.kinsoku {
+		case KINSOKU_HANGING:
+			if !kinsokuHanging[r] {
+				return n
+			}
+		case KINSOKU_PUSH_IN:
+			class := lineBreakClass(r)
+			if class != lbCL && class != lbEX && class != lbIS && class != lbNS {
+				return n
+			}
+		default:
+			return 0
+		}
+		n++
+	}
+
+	return n
+}
+
+// 片段 text[ss:se] 断字之后, 当前行(从 ls 开始)的结束位置, 0 表示不能断字
+func (lb lineBreaker) hyphenate(text []rune, ls, ss, se int) int {
+	if lb.hyphenator == nil {
+		return 0
+	}
+
+	points := lb.hyphenator.hyphenateSegment(text[ss:se])
+	for k := len(points) - 1; k >= 0; k-- {
+		if lb.measureRange(text, ls, ss+points[k], true) <= lb.width {
+			return ss + points[k]
+		}
+	}
+
+	return 0
+}
+
+// 片段 text[ss:se] 在一行之内可以写入的结束位置, 至少是1个字符
+func (lb lineBreaker) fitRunes(text []rune, ss, se int) int {
+	n := ss + sort.Search(se-ss, func(i int) bool {
+		return lb.measureRange(text, ss, ss+i+1, false) > lb.width
+	})
+
+	// 组合字符不能和前面的字符分开
+	for n > ss && n < se && lineBreakClass(text[n]) == lbCM {
+		n--
+	}
+	if n == ss {
+		n = ss + 1
+	}
+
+	return n
+}
+
+func measureRunes(pdf *core.Report, text []rune) float64 {
+	if len(text) == 0 {
+		return 0
+	}
+	return pdf.MeasureTextWidth(string(text))
+}
+
+// 去掉 text[start:end] 末尾的空格之后的结束位置
+func trimRightSpace(text []rune, start, end int) int {
+	for end > start && text[end-1] == ' ' {
+		end--
+	}
+	return end
+}
+
+// 写入一行的内容.
+// 追い込み的行宽度超过 width 时, 压缩字符之间的间距; 两端对齐时, 把剩余的宽度分配到单词之间的空格,
+// 以及中文, 日文等字符之间.
+func writeLine(pdf *core.Report, x, y float64, line string, width float64, kinsoku int, justify bool) {
+	var (
+		text        = []rune(line)
+		lineWidth   = measureRunes(pdf, text)
+		gaps, count = lineGaps(text, lineWidth, width, kinsoku, justify)
+	)
+
+	if count == 0 {
+		pdf.Cell(x, y, line)
+		return
+	}
+
+	// 按照间距拆分写入
+	var (
+		space = (width - lineWidth) / float64(count)
+		start = 0
+	)
+	for i := 1; i <= len(text); i++ {
+		if i < len(text) && !gaps[i] {
+			continue
+		}
+
+		piece := string(text[start:i])
+		pdf.Cell(x, y, piece)
+		x += pdf.MeasureTextWidth(piece) + space
+		start = i
+	}
+}
+
+// 一行之中可以调整的间距, gaps[i] 表示 text[i] 之前的间距可以调整, count 是可以调整的间距个数
+func lineGaps(text []rune, lineWidth, width float64, kinsoku int, justify bool) (gaps []bool, count int) {
+	switch {
+	case kinsoku == KINSOKU_PUSH_IN && lineWidth > width:
+		gaps, count = make([]bool, len(text)), len(text)-1
+		for i := 1; i < len(text); i++ {
+			gaps[i] = true
+		}
+	case justify && lineWidth < width:
+		gaps, count = justifyGaps(text)
+	}
+
+	return gaps, count
+}
+
+// 两端对齐时可以调整的间距: 单词之间的空格(行首的缩进除外), 以及中文, 日文等表意文字的前后
+func justifyGaps(text []rune) (gaps []bool, count int) {
+	var (
+		indent = 0
+	)
+
+	gaps = make([]bool, len(text))
+	for indent < len(text) && text[indent] == ' ' {
+		indent++
+	}
+
+	for i := indent + 1; i < len(text); i++ {
+		prev, curr := text[i-1], text[i]
+		switch {
+		case prev == ' ' && curr != ' ':
+			gaps[i] = true
+		case prev != ' ' && curr != ' ' && (isCJK(prev) || isCJK(curr)) && lineBreakClass(curr) != lbCM:
+			gaps[i] = true
+		}
+
+		if gaps[i] {
+			count++
+		}
+	}
+
+	return gaps, count
+}
+
+// 中文, 日文, 韩文的字符和标点符号
+func isCJK(r rune) bool {
+	return r >= 0x2E80 && lineBreakClass(r) != lbAL && lineBreakClass(r) != lbNU
+}
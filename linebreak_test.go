@@ -0,0 +1,284 @@
+package gopdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const LINEBREAK_MD = "MPBOLD"
+
+func LineBreakReport(content string, width float64) (lines []string, measure func(string) float64) {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: LINEBREAK_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		div := NewDivWithWidth(width, 12, 2, report)
+		div.SetFont(core.Font{Family: LINEBREAK_MD, Size: 10})
+		div.SetContent(content)
+		div.GenerateAtomicCell()
+
+		lines = div.contents
+		measure = report.MeasureTextWidth
+	}), core.Detail)
+
+	r.Execute("linebreak_test.pdf")
+	return lines, measure
+}
+
+func TestLineBreakOpportunities(t *testing.T) {
+	cases := []struct {
+		text   string
+		breaks string // "|" 表示可以在之前换行
+	}{
+		{"hello world", "hello |world"},
+		{"well-known fact", "well-|known |fact"},
+		{"price: $100, 50%", "price: |$100, |50%"},
+		{"(see above)", "(see |above)"},
+		{"中文分行。", "中|文|分|行。"},
+		{"「你好」，世界", "「你|好」，|世|界"},
+		{"パーティー", "パー|ティー"},
+		{"a b c", "a b |c"},
+	}
+
+	for _, c := range cases {
+		var (
+			text   = []rune(c.text)
+			breaks = lineBreakOpportunities(text)
+			got    []rune
+		)
+		for i, r := range text {
+			if breaks[i] {
+				got = append(got, '|')
+			}
+			got = append(got, r)
+		}
+
+		if string(got) != c.breaks {
+			t.Errorf("%q: expect %q, got %q", c.text, c.breaks, string(got))
+		}
+	}
+}
+
+func TestLineBreakWords(t *testing.T) {
+	content := "The quick brown fox jumps over the lazy dog. Pack my box with five dozen liquor jugs.\n\n" +
+		"Supercalifragilisticexpialidocious"
+
+	lines, measure := LineBreakReport(content, 100)
+	if len(lines) < 4 {
+		t.Fatalf("expect wrapped lines, got %q", lines)
+	}
+
+	var words []string
+	for _, line := range lines {
+		if measure(line) > 100 {
+			t.Errorf("line %q is wider than 100", line)
+		}
+		if strings.HasSuffix(line, " ") || strings.HasPrefix(line, " ") {
+			t.Errorf("line %q has leading or trailing space", line)
+		}
+		words = append(words, strings.Fields(line)...)
+	}
+
+	// 除了超长的单词, 单词不会被拆分
+	expect := strings.Fields(content)
+	long := strings.Join(words[len(expect)-1:], "")
+	if strings.Join(words[:len(expect)-1], " ") != strings.Join(expect[:len(expect)-1], " ") {
+		t.Errorf("words are split: %q", lines)
+	}
+	if long != expect[len(expect)-1] || len(words) == len(expect) {
+		t.Errorf("long word: %q", words[len(expect)-1:])
+	}
+
+	// 空行保留
+	var empty bool
+	for _, line := range lines {
+		empty = empty || line == ""
+	}
+	if !empty {
+		t.Errorf("empty line is dropped: %q", lines)
+	}
+}
+
+func TestLineBreakCJK(t *testing.T) {
+	content := "日本語の文章は、単語の間に空白がありません。文字ごとに改行できます。"
+
+	lines, _ := LineBreakReport(content, 60)
+	if len(lines) < 2 {
+		t.Fatalf("expect wrapped lines, got %q", lines)
+	}
+	if strings.Join(lines, "") != content {
+		t.Errorf("content changed: %q", lines)
+	}
+	for _, line := range lines[1:] {
+		if strings.HasPrefix(line, "、") || strings.HasPrefix(line, "。") {
+			t.Errorf("line %q starts with closing punctuation", line)
+		}
+	}
+}
+
+func TestLineBreakKinsoku(t *testing.T) {
+	var (
+		lines = map[int][]string{}
+		cells []string
+	)
+
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: LINEBREAK_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		report.SetFontWithStyle(LINEBREAK_MD, "", 10)
+		width := report.MeasureTextWidth("あいうえお")
+
+		for _, kinsoku := range []int{KINSOKU_PUSH_OUT, KINSOKU_HANGING, KINSOKU_PUSH_IN} {
+			div := NewDivWithWidth(width, 12, 2, report)
+			div.SetFont(core.Font{Family: LINEBREAK_MD, Size: 10})
+			div.SetKinsoku(kinsoku).SetContent("あいうえお。かきくけこ（さしすせ）")
+
+			lines[kinsoku] = div.contents
+			if kinsoku == KINSOKU_PUSH_IN {
+				count := len(*report.GetAtomicCells())
+				div.GenerateAtomicCell()
+				cells = (*report.GetAtomicCells())[count:]
+			}
+		}
+	}), core.Detail)
+
+	r.Execute("linebreak_test.pdf")
+
+	expect := map[int][]string{
+		KINSOKU_PUSH_OUT: {"あいうえ", "お。かきく", "けこ（さし", "すせ）"},
+		KINSOKU_HANGING:  {"あいうえお。", "かきくけこ", "（さしす", "せ）"},
+		KINSOKU_PUSH_IN:  {"あいうえお。", "かきくけこ", "（さしすせ）"},
+	}
+	for kinsoku, lines := range lines {
+		if strings.Join(lines, "/") != strings.Join(expect[kinsoku], "/") {
+			t.Errorf("kinsoku %v: expect %q, got %q", kinsoku, expect[kinsoku], lines)
+		}
+	}
+
+	// 追い込み的行逐个字符写入
+	var chars int
+	for _, cell := range cells {
+		if strings.HasPrefix(cell, "CL|") && len([]rune(cell[strings.LastIndex(cell, "|")+1:])) == 1 {
+			chars++
+		}
+	}
+	if chars != 12 {
+		t.Errorf("push in lines are not compressed: %v", cells)
+	}
+}
+
+func TestLineBreakJustify(t *testing.T) {
+	var (
+		lines     []string
+		lastLines []bool
+		cells     []string
+		width     float64
+		measure   func(string) float64
+	)
+
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: LINEBREAK_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		width, measure = 150, report.MeasureTextWidth
+
+		div := NewDivWithWidth(width, 12, 2, report)
+		div.SetFont(core.Font{Family: LINEBREAK_MD, Size: 10})
+		div.Justify().SetContent("The quick brown fox jumps over the lazy dog. Pack my box with five dozen liquor jugs.\n" +
+			"あいうえおかきくけこさしすせそたちつてとなにぬねの")
+
+		lines, lastLines = div.contents, div.lastLines
+		count := len(*report.GetAtomicCells())
+		div.GenerateAtomicCell()
+		cells = (*report.GetAtomicCells())[count:]
+	}), core.Detail)
+
+	r.Execute("linebreak_test.pdf")
+
+	// 每一行的左右边界
+	var (
+		ys    []string
+		left  = map[string]float64{}
+		right = map[string]float64{}
+	)
+	for _, cell := range cells {
+		if !strings.HasPrefix(cell, "CL|") {
+			continue
+		}
+
+		tokens := strings.SplitN(cell, "|", 4)
+		x, _ := strconv.ParseFloat(tokens[1], 64)
+		if _, ok := left[tokens[2]]; !ok {
+			ys = append(ys, tokens[2])
+			left[tokens[2]] = x
+		}
+		right[tokens[2]] = x + measure(tokens[3])
+	}
+
+	if len(ys) != len(lines) || len(lines) < 4 {
+		t.Fatalf("lines %q, cells %v", lines, cells)
+	}
+
+	var paragraph int
+	for i, y := range ys {
+		if lastLines[i] {
+			paragraph++
+			if right[y]-left[y] > width-1 {
+				t.Errorf("last line %q is justified", lines[i])
+			}
+			continue
+		}
+
+		if math.Abs(right[y]-left[y]-width) > 0.01 {
+			t.Errorf("line %q is not justified: %v", lines[i], right[y]-left[y])
+		}
+	}
+	if paragraph != 2 {
+		t.Errorf("expect 2 paragraphs, got %v", paragraph)
+	}
+}
+
+func TestLineBreakKinsokuInvalid(t *testing.T) {
+	r := core.CreateReport()
+	r.SetPage("A4", "P")
+
+	setters := []func(int){
+		func(k int) { NewDivWithWidth(100, 12, 2, r).SetKinsoku(k) },
+		func(k int) { NewSpan(12, 2, r).SetKinsoku(k) },
+		func(k int) { NewTextCell(100, 12, 2, r).SetKinsoku(k) },
+		func(k int) { NewRichTextWithWidth(100, 2, r).SetKinsoku(k) },
+	}
+	for i, set := range setters {
+		set(KINSOKU_HANGING)
+		for _, k := range []int{0, KINSOKU_PUSH_IN + 1} {
+			func() {
+				defer func() {
+					if recover() == nil {
+						t.Errorf("setter %v: expect panic for kinsoku %v", i, k)
+					}
+				}()
+				set(k)
+			}()
+		}
+	}
+}
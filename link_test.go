@@ -0,0 +1,74 @@
+package gopdf
+
+import (
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const LINK_MD = "MPBOLD"
+
+func LinkReport() *core.Report {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: LINK_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		_, y := report.GetXY()
+		report.Destination("top", y)
+
+		// 跨页的链接
+		x, _ := report.GetXY()
+		_, endY := report.GetPageEndXY()
+		report.SetXY(x, endY-30)
+		div := NewDivWithWidth(200, 12, 2, report)
+		div.SetFont(core.Font{Family: LINK_MD, Size: 10})
+		div.SetLink("https://example.com/div")
+		div.SetContent(strings.Repeat("link text wraps across lines and pages. ", 10))
+		div.GenerateAtomicCell()
+
+		x, y = report.GetXY()
+		report.LinkArea(x, y, 100, 20, "#page=1")
+		report.LinkArea(x, y+20, 100, 20, "#top")
+	}), core.Detail)
+
+	return r
+}
+
+func TestLinkArea(t *testing.T) {
+	r := LinkReport()
+	data := string(r.GetBytesPdf())
+
+	var (
+		page  int
+		areas = map[int]int{}
+	)
+	for _, cell := range *r.GetAtomicCells() {
+		if cell == "NP" {
+			page++
+		}
+		if strings.HasPrefix(cell, "LA|") && strings.HasSuffix(cell, "|https://example.com/div") {
+			areas[page]++
+		}
+	}
+
+	if len(areas) != 2 || areas[0] == 0 || areas[1] == 0 {
+		t.Errorf("div link areas: %v", areas)
+	}
+
+	uris := regexp.MustCompile(`/URI \(https://example.com/div\)`).FindAllString(data, -1)
+	if len(uris) != areas[0]+areas[1] {
+		t.Errorf("expect %v uri annotations, got %v", areas[0]+areas[1], len(uris))
+	}
+
+	dests := regexp.MustCompile(`/Dest \[\d+ 0 R /XYZ 0 [\d.]+ null\]`).FindAllString(data, -1)
+	if len(dests) != 2 {
+		t.Errorf("expect 2 internal links, got %v", dests)
+	}
+}
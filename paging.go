@@ -0,0 +1,278 @@
+package gopdf
+
+import (
+	"fmt"
+	"math"
+	"sort"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+/**
+Table的列分页.
+
+表格的宽度超过内容区域的时候, 按列拆分成多个部分(strip), 每一部分从新的页面开始, 依次写入.
+关键列(例如 ID, 名称)在每一部分重复, 其余的列按照顺序分配到各个部分. 跨列的单元格不能拆分,
+不能同时跨越关键列和其余的列.
+
+列分页需要使用 SetColumnWidths 设置绝对宽度或者 auto(使用内容的最大宽度), 表格的宽度是所有列宽度之和.
+所有部分使用相同的行高(整行的最大高度), 同一行在每一部分的位置相同.
+关键列的单元格元素需要实现 CopyableCell.
+**/
+
+// 开启列分页, keys 是每一部分重复的列. 需要在 SetColumnWidths 之前调用
+func (table *Table) SetColumnPaging(keys ...int) {
+	for _, col := range keys {
+		if col < 0 || col >= table.cols {
+			panic("invalid key column")
+		}
+	}
+
+	table.paging = true
+	table.keyCols = make(map[int]bool)
+	for _, col := range keys {
+		table.keyCols[col] = true
+	}
+}
+
+// 每一部分的标签, 写在每一页表格的上方. format 包含两个 %d, 是当前部分开始和结束的列(从 1 开始,
+// 不包括关键列), 例如 "columns %d–%d"
+func (table *Table) SetColumnLabel(format string, font core.Font) {
+	table.labelFormat = format
+	table.labelFont = font
+}
+
+// 列分页的宽度: 绝对宽度, auto 列使用最大宽度(不超过 limit)
+func (table *Table) resolvePagingColumns(maxs []float64, limit float64) {
+	var (
+		widths = make([]float64, table.cols)
+		total  float64
+	)
+	for i, c := range table.columns {
+		switch c.kind {
+		case columnPoint:
+			widths[i] = c.value
+		case columnAuto:
+			if maxs != nil {
+				widths[i] = math.Min(maxs[i], limit)
+			}
+		default:
+			panic("column paging requires point or auto column widths")
+		}
+		total += widths[i]
+	}
+
+	table.width = total
+	for i := range widths {
+		if total > 0 {
+			table.colwidths[i] = widths[i] / total
+		}
+	}
+	table.cachedCol = nil
+}
+
+// 拆分的各个部分, 每一部分是列的序号. 不需要拆分的时候返回 nil
+func (table *Table) columnStrips(available float64) [][]int {
+	if !table.paging || table.width <= available+0.01 {
+		return nil
+	}
+
+	var (
+		width = func(col int) float64 { return table.colwidths[col] * table.width }
+		keys  []int
+		space = available
+	)
+	for col := 0; col < table.cols; col++ {
+		if table.keyCols[col] {
+			keys = append(keys, col)
+			space -= width(col)
+		}
+	}
+
+	// 不能拆分的列的范围(跨列的单元格)
+	units := make([]int, table.cols) // 每一列所在范围的结束位置
+	for col := range units {
+		units[col] = col + 1
+	}
+	for i := 0; i < table.rows; i++ {
+		for j := 0; j < table.cols; j++ {
+			cell := table.cells[i][j]
+			if cell.rowspan < 1 || cell.colspan == 1 {
+				continue
+			}
+			for k := cell.col; k < cell.col+cell.colspan; k++ {
+				if table.keyCols[k] != table.keyCols[cell.col] {
+					panic("the colspan cell crosses key columns")
+				}
+				units[k] = int(math.Max(float64(units[k]), float64(cell.col+cell.colspan)))
+			}
+		}
+	}
+
+	var (
+		strips [][]int
+		chunk  []int
+		used   float64
+	)
+	for col := 0; col < table.cols; {
+		if table.keyCols[col] {
+			col++
+			continue
+		}
+
+		// 跨列的单元格可能继续延伸
+		end := units[col]
+		for k := col; k < end; k++ {
+			if units[k] > end {
+				end = units[k]
+			}
+		}
+
+		var unit float64
+		for k := col; k < end; k++ {
+			unit += width(k)
+		}
+		if len(chunk) > 0 && used+unit > space+0.01 {
+			strips = append(strips, chunk)
+			chunk, used = nil, 0
+		}
+		for k := col; k < end; k++ {
+			chunk = append(chunk, k)
+		}
+		used += unit
+		col = end
+	}
+	if len(chunk) > 0 {
+		strips = append(strips, chunk)
+	}
+
+	for i := range strips {
+		strips[i] = append(strips[i], keys...)
+		sort.Ints(strips[i])
+	}
+	return strips
+}
+
+// 依次写入各个部分, 每一部分从新的页面开始
+func (table *Table) generateStrips(strips [][]int) error {
+	var (
+		x, _      = table.pdf.GetXY()
+		_, startY = table.pdf.GetPageStartXY()
+		heights   = table.stripRowHeights()
+	)
+
+	// 关键列的副本需要在写入之前创建
+	tables := make([]*Table, len(strips))
+	for i, cols := range strips {
+		tables[i] = table.stripTable(cols, heights, i > 0)
+	}
+
+	for i, t := range tables {
+		if i > 0 {
+			table.pdf.AddNewPage(false)
+			table.pdf.SetXY(x, startY)
+		}
+		t.GenerateAtomicCell()
+	}
+	return nil
+}
+
+// 每一行的高度(只计算单行的单元格)
+func (table *Table) stripRowHeights() []float64 {
+	heights := make([]float64, table.rows)
+	for i := range heights {
+		heights[i] = table.lineHeight
+		for _, cell := range table.cells[i] {
+			if cell.rowspan == 1 {
+				heights[i] = math.Max(heights[i], table.cellHeight(cell))
+			}
+		}
+	}
+	return heights
+}
+
+// 使用 cols 列构建新的表格. copied 表示关键列使用元素的副本
+func (table *Table) stripTable(cols []int, heights []float64, copied bool) *Table {
+	var (
+		index = make(map[int]int) // 原来的列 -> 新的列
+		width float64
+	)
+	for k, col := range cols {
+		index[col] = k
+		width += table.colwidths[col] * table.width
+	}
+
+	t := &Table{
+		pdf:        table.pdf,
+		rows:       table.rows,
+		cols:       len(cols),
+		width:      width,
+		lineHeight: table.lineHeight,
+		margin:     table.margin,
+		rowheights: heights,
+		cells:      make([][]*TableCell, table.rows),
+		styled:     true,
+
+		outerBorder:    table.outerBorder,
+		innerBorder:    table.innerBorder,
+		headerRows:     table.headerRows,
+		footerRows:     table.footerRows,
+		keepTogether:   table.keepTogether,
+		keepWithNext:   table.keepWithNext,
+		minLinesBefore: table.minLinesBefore,
+		minLinesAfter:  table.minLinesAfter,
+	}
+	for _, col := range cols {
+		t.colwidths = append(t.colwidths, table.colwidths[col]*table.width/width)
+	}
+
+	// 标签: 不包括关键列的开始和结束的列
+	if table.labelFormat != "" {
+		first, last := -1, -1
+		for _, col := range cols {
+			if !table.keyCols[col] {
+				if first == -1 {
+					first = col
+				}
+				last = col
+			}
+		}
+		t.label = fmt.Sprintf(table.labelFormat, first+1, last+1)
+		t.labelFont = table.labelFont
+	}
+
+	for i := range t.cells {
+		t.cells[i] = make([]*TableCell, len(cols))
+		for k, col := range cols {
+			cell := table.cells[i][col]
+			c := *cell
+			c.table, c.col = t, k
+			if c.rowspan < 1 {
+				c.colspan = -index[-cell.colspan]
+			}
+
+			if copied && table.keyCols[col] && cell.element != nil && cell.rowspan >= 1 {
+				e, ok := cell.element.(CopyableCell)
+				if !ok {
+					panic("the element of key column cells must be CopyableCell")
+				}
+				c.element = e.CopyCell()
+			}
+			t.cells[i][k] = &c
+		}
+	}
+
+	return t
+}
+
+// 在 (x, y) 写入标签
+func (table *Table) writeLabel(x, y float64) {
+	if table.label == "" {
+		return
+	}
+
+	font := table.labelFont
+	table.pdf.Font(font.Family, font.Size, font.Style)
+	table.pdf.SetFontWithStyle(font.Family, font.Style, font.Size)
+	table.pdf.Cell(x, y, table.label)
+}
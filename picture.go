@@ -1,11 +1,14 @@
 package gopdf
 
 import (
+	"bytes"
+	"encoding/binary"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/jpeg"
 	"image/png"
+	"io/ioutil"
 	"os"
 )
 
@@ -66,8 +69,10 @@ func ConvertPNG2JPEG(srcPath, dstPath string) (err error) {
 	}
 	defer dstFile.Close()
 
+	// 透明的部分使用白色背景填充
 	dstImage := image.NewRGBA(srcImage.Bounds())
-	draw.Draw(dstImage, dstImage.Bounds(), srcImage, srcImage.Bounds().Min, draw.Src)
+	draw.Draw(dstImage, dstImage.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(dstImage, dstImage.Bounds(), srcImage, srcImage.Bounds().Min, draw.Over)
 
 	return jpeg.Encode(dstFile, dstImage, nil)
 }
@@ -94,3 +99,160 @@ func DrawPNG(srcPath string) {
 	// 以png的格式写入文件
 	png.Encode(pngFile, pngImage)
 }
+
+// 获取图片的分辨率(DPI), 支持 JPEG(JFIF, Exif) 和 PNG(pHYs), 没有分辨率信息时返回 0
+func GetImageDPI(picturePath string) (xdpi, ydpi float64) {
+	buf, err := ioutil.ReadFile(picturePath)
+	if err != nil {
+		return 0, 0
+	}
+
+	switch {
+	case bytes.HasPrefix(buf, []byte("\x89PNG\r\n\x1a\n")):
+		return getPNGDPI(buf)
+	case bytes.HasPrefix(buf, []byte{0xFF, 0xD8}):
+		return getJPEGDPI(buf)
+	}
+
+	return 0, 0
+}
+
+// PNG 的 pHYs 块: [ppux(4), ppuy(4), unit(1)], unit=1 表示单位是米
+func getPNGDPI(buf []byte) (xdpi, ydpi float64) {
+	for i := 8; i+8 <= len(buf); {
+		length := int(binary.BigEndian.Uint32(buf[i : i+4]))
+		chunk := string(buf[i+4 : i+8])
+		if chunk == "IDAT" || chunk == "IEND" || i+8+length > len(buf) {
+			break
+		}
+
+		if chunk == "pHYs" && length >= 9 {
+			data := buf[i+8 : i+8+length]
+			if data[8] == 1 {
+				xdpi = float64(binary.BigEndian.Uint32(data[0:4])) * 0.0254
+				ydpi = float64(binary.BigEndian.Uint32(data[4:8])) * 0.0254
+			}
+			return xdpi, ydpi
+		}
+
+		i += 12 + length
+	}
+
+	return 0, 0
+}
+
+// JPEG 的 APP0(JFIF) 或 APP1(Exif) 段
+func getJPEGDPI(buf []byte) (xdpi, ydpi float64) {
+	for i := 2; i+4 <= len(buf); {
+		if buf[i] != 0xFF {
+			break
+		}
+
+		marker := buf[i+1]
+		length := int(binary.BigEndian.Uint16(buf[i+2 : i+4]))
+		if marker == 0xDA || i+2+length > len(buf) {
+			break
+		}
+
+		data := buf[i+4 : i+2+length]
+		switch {
+		case marker == 0xE0 && len(data) >= 12 && string(data[0:5]) == "JFIF\x00":
+			x := float64(binary.BigEndian.Uint16(data[8:10]))
+			y := float64(binary.BigEndian.Uint16(data[10:12]))
+			switch data[7] {
+			case 1:
+				return x, y
+			case 2:
+				return x * 2.54, y * 2.54
+			}
+		case marker == 0xE1 && len(data) >= 14 && string(data[0:6]) == "Exif\x00\x00":
+			if xdpi, ydpi = getExifDPI(data[6:]); xdpi > 0 && ydpi > 0 {
+				return xdpi, ydpi
+			}
+		}
+
+		i += 2 + length
+	}
+
+	return 0, 0
+}
+
+// Exif(TIFF格式) IFD0 当中的 XResolution, YResolution, ResolutionUnit
+func getExifDPI(tiff []byte) (xdpi, ydpi float64) {
+	var order binary.ByteOrder
+	switch string(tiff[0:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 0, 0
+	}
+
+	rational := func(offset uint32) float64 {
+		if int(offset)+8 > len(tiff) {
+			return 0
+		}
+		num, den := order.Uint32(tiff[offset:]), order.Uint32(tiff[offset+4:])
+		if den == 0 {
+			return 0
+		}
+		return float64(num) / float64(den)
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd+2 > len(tiff) {
+		return 0, 0
+	}
+
+	unit := uint16(2)
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for k := 0; k < count; k++ {
+		entry := ifd + 2 + k*12
+		if entry+12 > len(tiff) {
+			break
+		}
+
+		value := order.Uint32(tiff[entry+8 : entry+12])
+		switch order.Uint16(tiff[entry : entry+2]) {
+		case 0x011A:
+			xdpi = rational(value)
+		case 0x011B:
+			ydpi = rational(value)
+		case 0x0128:
+			unit = order.Uint16(tiff[entry+8 : entry+10])
+		}
+	}
+
+	if unit == 3 {
+		return xdpi * 2.54, ydpi * 2.54
+	}
+
+	return xdpi, ydpi
+}
+
+// 按照 rect 裁剪图片, 并以 PNG 的格式保存(保留透明度)
+func CropImage(srcPath, dstPath string, rect image.Rectangle) (err error) {
+	srcFile, err := os.Open(srcPath)
+	if err != nil {
+		return
+	}
+	defer srcFile.Close()
+
+	srcImage, _, err := image.Decode(srcFile)
+	if err != nil {
+		return err
+	}
+
+	dstFile, err := os.Create(dstPath)
+	if err != nil {
+		return
+	}
+	defer dstFile.Close()
+
+	rect = rect.Add(srcImage.Bounds().Min).Intersect(srcImage.Bounds())
+	dstImage := image.NewNRGBA(image.Rect(0, 0, rect.Dx(), rect.Dy()))
+	draw.Draw(dstImage, dstImage.Bounds(), srcImage, rect.Min, draw.Src)
+
+	return png.Encode(dstFile, dstImage)
+}
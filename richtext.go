@@ -0,0 +1,371 @@
+package gopdf
+
+import (
+	"math"
+	"strings"
+
+	"github.com/tiechui1994/gopdf/core"
+	"github.com/tiechui1994/gopdf/util"
+)
+
+// 富文本中的一段文字, 同一段文字使用相同的字体, 颜色
+type TextRun struct {
+	Text  string
+	Font  core.Font // 字体, 大小, 风格
+	Color string    // 字体颜色, 默认是黑色
+	Link  string    // 链接, 格式参考 Report.LinkArea
+
+	Decoration TextDecoration // 文字装饰(下划线颜色, 双下划线, 删除线, 高亮)
+}
+
+// 富文本, 多段不同字体, 颜色的文字混排, 自动换行, 自动分页. 同一行的文字使用相同的基线
+type RichText struct {
+	pdf       *core.Report
+	runs      []TextRun
+	lines     []richLine
+	lineSpace float64 // 行间距
+
+	width, height float64
+	margin        core.Scope
+
+	horizontalCentered bool
+	rightAlign         bool
+	justify            bool
+	kinsoku            int    // 禁则处理方式
+	language           string // 断字的语言, 默认使用第一段文字的字体的语言
+
+	metrics map[core.Font][2]float64 // 字体的上升高度和下降高度
+}
+
+// 富文本的一行
+type richLine struct {
+	runes  []rune
+	owners []int // 每个字符所属的 TextRun
+
+	width           float64
+	ascent, descent float64
+	last            bool // 段落的最后一行
+}
+
+func NewRichText(lineSpace float64, pdf *core.Report) *RichText {
+	currX, _ := pdf.GetXY()
+	endX, _ := pdf.GetPageEndXY()
+	if endX-currX <= 0 {
+		panic("please modify current X")
+	}
+
+	return &RichText{
+		pdf:       pdf,
+		width:     endX - currX,
+		lineSpace: lineSpace,
+		metrics:   make(map[core.Font][2]float64),
+	}
+}
+
+func NewRichTextWithWidth(width, lineSpace float64, pdf *core.Report) *RichText {
+	currX, _ := pdf.GetXY()
+	endX, _ := pdf.GetPageEndXY()
+	if endX-currX <= 0 {
+		panic("please modify current X")
+	}
+
+	if endX-currX <= width {
+		width = endX - currX
+	}
+
+	return &RichText{
+		pdf:       pdf,
+		width:     width,
+		lineSpace: lineSpace,
+		metrics:   make(map[core.Font][2]float64),
+	}
+}
+
+func (rt *RichText) SetMarign(margin core.Scope) *RichText {
+	margin.ReplaceMarign()
+	x, _ := rt.pdf.GetXY()
+	endX, _ := rt.pdf.GetPageEndXY()
+
+	if x+margin.Left+rt.width > endX {
+		width := endX - x - margin.Left
+		if width <= 0 {
+			return rt
+		}
+		rt.width = width
+	}
+
+	rt.margin = margin
+	return rt
+}
+
+func (rt *RichText) GetHeight() float64 {
+	return rt.height
+}
+func (rt *RichText) GetWidth() float64 {
+	return rt.width
+}
+
+func (rt *RichText) HorizontalCentered() *RichText {
+	rt.horizontalCentered = true
+	rt.rightAlign = false
+	rt.justify = false
+	return rt
+}
+func (rt *RichText) RightAlign() *RichText {
+	rt.rightAlign = true
+	rt.horizontalCentered = false
+	rt.justify = false
+	return rt
+}
+
+// 两端对齐, 段落的最后一行居左
+func (rt *RichText) Justify() *RichText {
+	rt.justify = true
+	rt.horizontalCentered = false
+	rt.rightAlign = false
+	return rt
+}
+
+// 设置禁则处理方式, 默认是 KINSOKU_PUSH_OUT, 必须在 SetContent 之前设置
+func (rt *RichText) SetKinsoku(kinsoku int) *RichText {
+	checkKinsoku(kinsoku)
+	rt.kinsoku = kinsoku
+	return rt
+}
+
+// 设置断字的语言, 必须在 SetContent 之前设置
+func (rt *RichText) SetLanguage(language string) *RichText {
+	rt.language = language
+	return rt
+}
+
+// 设置内容, 所有的文字一起进行分行("\n"强制换行)
+func (rt *RichText) SetContent(runs ...TextRun) *RichText {
+	if len(runs) == 0 {
+		panic("there no avliable run")
+	}
+
+	var (
+		text   []rune
+		owners []int
+	)
+	for i, run := range runs {
+		if util.IsEmpty(run.Font) {
+			panic("there no avliable font")
+		}
+		if run.Color != "" {
+			util.CheckColor(run.Color)
+		}
+		checkDecoration(run.Decoration)
+
+		content := strings.Replace(run.Text, "\t", "    ", -1)
+		content = strings.Replace(content, "\r", "", -1)
+		for _, r := range content {
+			text = append(text, r)
+			owners = append(owners, i)
+		}
+	}
+
+	rt.runs = runs
+	rt.lines = nil
+
+	// 按照 "\n" 分段, 空的段落使用换行符的字体
+	start := 0
+	for i := 0; i <= len(text); i++ {
+		if i < len(text) && text[i] != '\n' {
+			continue
+		}
+
+		owner := 0
+		if i < len(text) {
+			owner = owners[i]
+		} else if i > 0 {
+			owner = owners[i-1]
+		}
+		rt.breakParagraph(text[start:i], owners[start:i], owner)
+		start = i + 1
+	}
+
+	// 重新计算高度
+	rt.height = 0
+	for i, line := range rt.lines {
+		rt.height += line.ascent + line.descent
+		if i > 0 {
+			rt.height += rt.lineSpace
+		}
+	}
+
+	return rt
+}
+
+func (rt *RichText) breakParagraph(text []rune, owners []int, owner int) {
+	var (
+		hyphenator = getHyphenator(rt.language)
+	)
+	if rt.language == "" {
+		hyphenator = getHyphenator(rt.runs[0].Font.Language)
+	}
+
+	lb := lineBreaker{
+		pdf:        rt.pdf,
+		width:      rt.width,
+		kinsoku:    rt.kinsoku,
+		hyphenator: hyphenator,
+		measure: func(text []rune, start, end int, hyphen bool) float64 {
+			return rt.measure(text[start:end], owners[start:end], hyphen, hyphenator)
+		},
+	}
+
+	ranges := lb.breakParagraph(text)
+	for i, r := range ranges {
+		line := richLine{
+			runes:  append([]rune{}, text[r.start:r.end]...),
+			owners: append([]int{}, owners[r.start:r.end]...),
+			last:   i == len(ranges)-1,
+		}
+		if r.hyphen {
+			last := owners[r.end-1]
+			for _, c := range hyphenator.Hyphen {
+				line.runes = append(line.runes, c)
+				line.owners = append(line.owners, last)
+			}
+		}
+
+		line.width = rt.measure(line.runes, line.owners, false, nil)
+		if len(line.owners) == 0 {
+			line.ascent, line.descent = rt.fontHeight(rt.runs[owner].Font)
+		}
+		for _, o := range line.owners {
+			ascent, descent := rt.fontHeight(rt.runs[o].Font)
+			line.ascent = math.Max(line.ascent, ascent)
+			line.descent = math.Max(line.descent, descent)
+		}
+
+		rt.lines = append(rt.lines, line)
+	}
+}
+
+// 文字的宽度, 按照所属的 TextRun 分别计算
+func (rt *RichText) measure(text []rune, owners []int, hyphen bool, hyphenator *Hyphenator) float64 {
+	var (
+		width float64
+		start int
+	)
+
+	for i := 1; i <= len(text); i++ {
+		if i < len(text) && owners[i] == owners[start] {
+			continue
+		}
+
+		width += rt.pdf.MeasureTextWidthWithFont(rt.runs[owners[start]].Font, string(text[start:i]))
+		start = i
+	}
+
+	if hyphen && len(owners) > 0 {
+		width += rt.pdf.MeasureTextWidthWithFont(rt.runs[owners[len(owners)-1]].Font, hyphenator.Hyphen)
+	}
+
+	return width
+}
+
+func (rt *RichText) fontHeight(font core.Font) (ascent, descent float64) {
+	font.Style = strings.Replace(font.Style, "U", "", -1)
+	if metric, ok := rt.metrics[font]; ok {
+		return metric[0], metric[1]
+	}
+
+	ascent, descent = rt.pdf.MeasureFontHeight(font)
+	rt.metrics[font] = [2]float64{ascent, descent}
+	return ascent, descent
+}
+
+// 自动分页
+func (rt *RichText) GenerateAtomicCell() error {
+	var (
+		sx, sy      = rt.pdf.GetXY()
+		x           = sx + rt.margin.Left
+		y           = sy + rt.margin.Top
+		_, pageEndY = rt.pdf.GetPageEndXY()
+	)
+
+	for _, line := range rt.lines {
+		// 换页
+		_, pageStartY := rt.pdf.GetPageStartXY()
+		if y+line.ascent+line.descent > pageEndY && y > pageStartY {
+			rt.pdf.AddNewPage(false)
+			rt.pdf.SetXY(sx, pageStartY)
+			y = pageStartY
+		}
+
+		rt.writeLine(x, y, line)
+		y += line.ascent + line.descent + rt.lineSpace
+	}
+
+	x, _ = rt.pdf.GetPageStartXY()
+	rt.pdf.SetXY(x, y-rt.lineSpace+rt.margin.Bottom) // 定格最终的位置
+
+	return nil
+}
+
+// 写入一行, 按照 TextRun 和可以调整的间距拆分成多个片段
+func (rt *RichText) writeLine(x, y float64, line richLine) {
+	var (
+		baseline    = y + line.ascent
+		gaps, count = lineGaps(line.runes, line.width, rt.width, rt.kinsoku, rt.justify && !line.last)
+		space       float64
+		start       int
+	)
+
+	switch {
+	case count > 0:
+		space = (rt.width - line.width) / float64(count)
+	case rt.horizontalCentered:
+		x += (rt.width - line.width) / 2
+	case rt.rightAlign:
+		x += rt.width - line.width
+	}
+
+	for i := 1; i <= len(line.runes); i++ {
+		if i < len(line.runes) && line.owners[i] == line.owners[start] && (count == 0 || !gaps[i]) {
+			continue
+		}
+
+		var (
+			run     = rt.runs[line.owners[start]]
+			content = string(line.runes[start:i])
+			font    = run.Font
+		)
+
+		ascent, descent := rt.fontHeight(font)
+		width := rt.pdf.MeasureTextWidthWithFont(font, content)
+
+		// 同一段文字的装饰跨过调整的间距
+		extent := width
+		gap := i < len(line.runes) && count > 0 && gaps[i]
+		if gap && line.owners[i] == line.owners[start] {
+			extent += space
+		}
+		if run.Decoration.Highlight != "" {
+			rt.pdf.BackgroundColor(x, baseline-ascent, extent, ascent+descent, run.Decoration.Highlight, "0000")
+		}
+
+		if !util.IsEmpty(run.Color) {
+			rt.pdf.TextColor(util.GetColorRGB(run.Color))
+		}
+		rt.pdf.Font(font.Family, font.Size, font.Style)
+		rt.pdf.Cell(x, baseline-ascent, content)
+		if !util.IsEmpty(run.Color) {
+			rt.pdf.TextDefaultColor()
+		}
+		run.Decoration.drawLinesAt(rt.pdf, x, baseline, extent, font.Size)
+		if run.Link != "" {
+			rt.pdf.LinkArea(x, baseline-ascent, width, ascent+descent, run.Link)
+		}
+
+		x += width
+		if gap {
+			x += space
+		}
+		start = i
+	}
+}
@@ -0,0 +1,135 @@
+package gopdf
+
+import (
+	"math"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const RICH_MD = "MPBOLD"
+
+func RichTextReport(runs ...TextRun) (rich *RichText, cells []string) {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: RICH_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		count := len(*report.GetAtomicCells())
+
+		rich = NewRichTextWithWidth(200, 2, report)
+		rich.SetContent(runs...).GenerateAtomicCell()
+
+		cells = (*report.GetAtomicCells())[count:]
+	}), core.Detail)
+
+	r.Execute("richtext_test.pdf")
+	return rich, cells
+}
+
+func TestRichTextBaseline(t *testing.T) {
+	var (
+		normal = core.Font{Family: RICH_MD, Size: 10}
+		large  = core.Font{Family: RICH_MD, Size: 20}
+	)
+
+	rich, cells := RichTextReport(
+		TextRun{Text: "Total: ", Font: normal},
+		TextRun{Text: "1,234", Font: large, Color: "255,0,0"},
+		TextRun{Text: " see ", Font: normal},
+		TextRun{Text: "details", Font: normal, Decoration: TextDecoration{Underline: true}, Link: "https://example.com/a|b"},
+	)
+
+	if len(rich.lines) != 1 {
+		t.Fatalf("expect 1 line, got %v", len(rich.lines))
+	}
+
+	// 每个片段的基线相同
+	var (
+		fonts     []core.Font
+		baselines []float64
+		texts     []string
+		links     []string
+		current   core.Font
+	)
+	for _, cell := range cells {
+		tokens := strings.Split(cell, "|")
+		switch tokens[0] {
+		case "F":
+			size, _ := strconv.Atoi(tokens[3])
+			current = core.Font{Family: tokens[1], Style: tokens[2], Size: size}
+		case "CL":
+			y, _ := strconv.ParseFloat(tokens[2], 64)
+			ascent, _ := rich.fontHeight(current)
+			fonts = append(fonts, current)
+			baselines = append(baselines, y+ascent)
+			texts = append(texts, tokens[3])
+		case "LA":
+			links = append(links, strings.Join(tokens[5:], "|"))
+		}
+	}
+
+	if strings.Join(texts, "") != "Total: 1,234 see details" {
+		t.Fatalf("texts: %q", texts)
+	}
+	for _, baseline := range baselines {
+		if math.Abs(baseline-baselines[0]) > 0.01 {
+			t.Errorf("baselines are different: %v", baselines)
+		}
+	}
+	if fonts[1].Size != 20 {
+		t.Errorf("fonts: %v", fonts)
+	}
+	// 下划线通过 Decoration 绘制, 不修改字体风格
+	if _, widths := decorationPaths(cells); fonts[len(fonts)-1].Style != "" || len(widths) != 1 {
+		t.Errorf("underline: %v %v", fonts[len(fonts)-1], widths)
+	}
+	if len(links) != 1 || links[0] != "https://example.com/a|b" {
+		t.Errorf("links: %v", links)
+	}
+
+	ascent, descent := rich.fontHeight(large)
+	if math.Abs(rich.GetHeight()-ascent-descent) > 0.01 {
+		t.Errorf("height: %v", rich.GetHeight())
+	}
+}
+
+func TestRichTextWrap(t *testing.T) {
+	var (
+		normal = core.Font{Family: RICH_MD, Size: 10}
+		bold   = core.Font{Family: RICH_MD, Size: 12}
+	)
+
+	var runs []TextRun
+	for i := 0; i < 100; i++ {
+		runs = append(runs, TextRun{Text: "The quick brown fox ", Font: normal},
+			TextRun{Text: "jumps", Font: bold, Color: "0,0,255"}, TextRun{Text: " over the lazy dog. ", Font: normal})
+	}
+
+	rich, cells := RichTextReport(runs...)
+
+	var pages int
+	for _, cell := range cells {
+		if cell == "NP" {
+			pages++
+		}
+	}
+	if pages == 0 {
+		t.Errorf("expect page break, lines %v", len(rich.lines))
+	}
+
+	for _, line := range rich.lines {
+		if line.width > rich.width {
+			t.Errorf("line %q is wider than %v", string(line.runes), rich.width)
+		}
+		if strings.HasPrefix(string(line.runes), "umps") || strings.HasSuffix(string(line.runes), " j") {
+			t.Errorf("word is split: %q", string(line.runes))
+		}
+	}
+}
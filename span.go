@@ -2,7 +2,6 @@ package gopdf
 
 import (
 	"math"
-	"strings"
 
 	"github.com/tiechui1994/gopdf/core"
 	"github.com/tiechui1994/gopdf/util"
@@ -22,9 +21,14 @@ type Span struct {
 	border    core.Scope
 
 	contents           []string
+	lastLines          []bool // 段落的最后一行
 	horizontalCentered bool
 	verticalCentered   bool
 	rightAlign         bool
+	justify            bool
+	kinsoku            int    // 禁则处理方式
+	language           string // 断字的语言, 默认使用字体的语言
+	decoration         TextDecoration
 }
 
 func NewSpan(lineHeight, lineSpce float64, pdf *core.Report) *Span {
@@ -74,6 +78,10 @@ func (span *Span) Copy(content string) *Span {
 		lineHeight: span.lineHeight,
 		lineSpace:  span.lineSpace,
 		fontColor:  span.fontColor,
+		kinsoku:    span.kinsoku,
+		justify:    span.justify,
+		language:   span.language,
+		decoration: span.decoration,
 	}
 
 	f.SetBorder(span.border)
@@ -159,6 +167,7 @@ func (span *Span) GetWidth() (width float64) {
 func (span *Span) HorizontalCentered() *Span {
 	span.horizontalCentered = true
 	span.rightAlign = false
+	span.justify = false
 	return span
 }
 func (span *Span) VerticalCentered() *Span {
@@ -168,6 +177,44 @@ func (span *Span) VerticalCentered() *Span {
 func (span *Span) RightAlign() *Span {
 	span.rightAlign = true
 	span.horizontalCentered = false
+	span.justify = false
+	return span
+}
+
+// 两端对齐, 段落的最后一行居左
+func (span *Span) Justify() *Span {
+	span.justify = true
+	span.horizontalCentered = false
+	span.rightAlign = false
+	return span
+}
+
+// 设置文字装饰(下划线, 删除线, 高亮等), 每一行单独绘制
+func (span *Span) SetDecoration(decoration TextDecoration) *Span {
+	checkDecoration(decoration)
+	span.decoration = decoration
+	return span
+}
+
+// 设置禁则处理方式, 默认是 KINSOKU_PUSH_OUT, 必须在 SetContent 之前设置
+func (span *Span) SetKinsoku(kinsoku int) *Span {
+	checkKinsoku(kinsoku)
+	span.kinsoku = kinsoku
+
+	return span
+}
+
+// 断字器, 组件的语言优先
+func (span *Span) hyphenator() *Hyphenator {
+	if span.language != "" {
+		return getHyphenator(span.language)
+	}
+	return getHyphenator(span.font.Language)
+}
+
+// 设置断字的语言, 必须在 SetContent 之前设置
+func (span *Span) SetLanguage(language string) *Span {
+	span.language = language
 	return span
 }
 
@@ -191,13 +238,6 @@ func (span *Span) SetFontWithColor(font core.Font, color string) *Span {
 }
 
 func (span *Span) SetContent(content string) *Span {
-	convertStr := strings.Replace(content, "\t", "    ", -1)
-
-	var (
-		blocks       = strings.Split(convertStr, "\n") // 分行
-		contentWidth = span.width
-	)
-
 	// 必须检查字体
 	if util.IsEmpty(span.font) {
 		panic("there no avliable font")
@@ -207,45 +247,13 @@ func (span *Span) SetContent(content string) *Span {
 	span.pdf.Font(span.font.Family, span.font.Size, span.font.Style)
 	span.pdf.SetFontWithStyle(span.font.Family, span.font.Style, span.font.Size)
 
-	if len(blocks) == 1 {
-		if span.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			span.contents = []string{convertStr}
-			height := span.border.Top + span.border.Bottom + span.lineHeight
-			span.height = math.Max(height, span.height)
-
-			return span
-		}
-	}
-
-	for i := range blocks {
-		// 单独的一行
-		if span.pdf.MeasureTextWidth(convertStr) < contentWidth {
-			span.contents = append(span.contents, blocks[i])
-			continue
-		}
-
-		var (
-			line []rune
-		)
-		// 单独的一行需要拆分
-		for _, r := range []rune(blocks[i]) {
-			line = append(line, r)
-			lineLength := span.pdf.MeasureTextWidth(string(line))
-			if lineLength >= contentWidth {
-				if lineLength-contentWidth > 2 {
-					span.contents = append(span.contents, string(line[0:len(line)-1]))
-					line = line[len(line)-1:]
-				} else {
-					span.contents = append(span.contents, string(line))
-					line = []rune{}
-				}
-			}
-		}
+	// 按照单词分行
+	span.contents, span.lastLines = lineBreaker{pdf: span.pdf, width: span.width, kinsoku: span.kinsoku, hyphenator: span.hyphenator()}.breakLines(content)
+	if len(span.contents) == 1 {
+		height := span.border.Top + span.border.Bottom + span.lineHeight
+		span.height = math.Max(height, span.height)
 
-		// 剩余单独成行
-		if len(line) > 0 {
-			span.contents = append(span.contents, string(line))
-		}
+		return span
 	}
 
 	// 重新计算 span 的高度
@@ -307,7 +315,11 @@ func (span *Span) GenerateAtomicCell() error {
 
 		x, y = span.getContentPosition(sx, sy, i)
 
-		span.pdf.Cell(x, y, span.contents[i])
+		justify := span.justify && !span.lastLines[i]
+		width := math.Min(lineExtent(span.pdf, span.contents[i], span.width, span.kinsoku, justify), span.width)
+		span.decoration.drawHighlight(span.pdf, x, y, width, span.font)
+		writeLine(span.pdf, x, y, span.contents[i], span.width, span.kinsoku, justify)
+		span.decoration.drawLines(span.pdf, x, y, width, span.font)
 	}
 
 	if !util.IsEmpty(span.fontColor) {
@@ -0,0 +1,320 @@
+package gopdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/tiechui1994/gopdf/core"
+	"github.com/tiechui1994/gopdf/util"
+)
+
+/**
+Table的行样式和条件格式.
+
+样式在 GenerateAtomicCell 的时候应用到单元格元素(需要实现 StylableCell), 后面的规则覆盖前面的规则:
+	1. 斑马纹, 表格主体的行依次循环使用 SetStripeStyles 的样式
+	2. 表头和表尾的样式
+	3. 条件格式, 按照单元格的数值设置样式, 只作用于表格主体. 不是数值的单元格被忽略
+	4. 行样式(SetRowStyle, 例如汇总行), 设置了行样式的行不使用斑马纹, 也不参与条件格式
+
+数值的格式: 可以包含千分位的逗号, 货币符号($, ¥, €, £)前缀和百分号后缀, "(123)" 表示负数.
+**/
+
+// 单元格的样式, 空值表示不修改
+type CellStyle struct {
+	BackColor string // 背景颜色
+	FontColor string // 字体颜色
+	FontStyle string // 追加的字体风格, "B", "I", "U" 的组合
+}
+
+func checkCellStyle(style CellStyle) {
+	if style.BackColor != "" {
+		util.CheckColor(style.BackColor)
+	}
+	if style.FontColor != "" {
+		util.CheckColor(style.FontColor)
+	}
+	for _, c := range style.FontStyle {
+		if !strings.ContainsRune("BIU", c) {
+			panic("invalid font style: " + style.FontStyle)
+		}
+	}
+}
+
+// 合并样式, other 当中设置的字段覆盖 style
+func (style CellStyle) merge(other CellStyle) CellStyle {
+	if other.BackColor != "" {
+		style.BackColor = other.BackColor
+	}
+	if other.FontColor != "" {
+		style.FontColor = other.FontColor
+	}
+	for _, c := range other.FontStyle {
+		if !strings.ContainsRune(style.FontStyle, c) {
+			style.FontStyle += string(c)
+		}
+	}
+	return style
+}
+
+// 可以设置样式的单元格元素
+type StylableCell interface {
+	core.Cell
+	GetContent() string // 原始内容, 条件格式使用
+	ApplyStyle(style CellStyle)
+}
+
+// 条件格式. value 是单元格的数值, min 和 max 是作用范围内所有数值的最小值和最大值
+type ConditionalFormat interface {
+	Format(value, min, max float64) (CellStyle, bool)
+}
+
+// 色阶, 按照数值在 [min, max] 当中的位置插值背景颜色. MidColor 为空的时候使用两种颜色
+type ColorScale struct {
+	MinColor string
+	MidColor string // 中间值((min+max)/2)的颜色
+	MaxColor string
+}
+
+func (scale ColorScale) Format(value, min, max float64) (CellStyle, bool) {
+	var (
+		t        = 0.0
+		from, to = scale.MinColor, scale.MaxColor
+	)
+	if max > min {
+		t = (value - min) / (max - min)
+	}
+
+	if scale.MidColor != "" {
+		if t < 0.5 {
+			to, t = scale.MidColor, t*2
+		} else {
+			from, t = scale.MidColor, t*2-1
+		}
+	}
+
+	return CellStyle{BackColor: interpolateColor(from, to, t)}, true
+}
+
+func interpolateColor(from, to string, t float64) string {
+	r1, g1, b1 := util.GetColorRGB(from)
+	r2, g2, b2 := util.GetColorRGB(to)
+	mix := func(a, b int) int {
+		return int(math.Round(float64(a) + (float64(b)-float64(a))*t))
+	}
+	return fmt.Sprintf("%d,%d,%d", mix(r1, r2), mix(g1, g2), mix(b1, b2))
+}
+
+// 阈值, 数值满足 Op Value 的时候使用 Style. Op 是 "<", "<=", ">", ">=", "==", "!=" 之一
+type Threshold struct {
+	Op    string
+	Value float64
+	Style CellStyle
+}
+
+func (threshold Threshold) Format(value, min, max float64) (CellStyle, bool) {
+	var ok bool
+	switch threshold.Op {
+	case "<":
+		ok = value < threshold.Value
+	case "<=":
+		ok = value <= threshold.Value
+	case ">":
+		ok = value > threshold.Value
+	case ">=":
+		ok = value >= threshold.Value
+	case "==":
+		ok = value == threshold.Value
+	case "!=":
+		ok = value != threshold.Value
+	}
+	return threshold.Style, ok
+}
+
+// 负数使用红色粗体
+func NegativeRedBold() Threshold {
+	return Threshold{Op: "<", Value: 0, Style: CellStyle{FontColor: "255,0,0", FontStyle: "B"}}
+}
+
+func checkConditionalFormat(format ConditionalFormat) {
+	switch f := format.(type) {
+	case nil:
+		panic("the conditional format is nil")
+	case ColorScale:
+		util.CheckColor(f.MinColor)
+		util.CheckColor(f.MaxColor)
+		if f.MidColor != "" {
+			util.CheckColor(f.MidColor)
+		}
+	case Threshold:
+		switch f.Op {
+		case "<", "<=", ">", ">=", "==", "!=":
+		default:
+			panic("invalid threshold op: " + f.Op)
+		}
+		checkCellStyle(f.Style)
+	}
+}
+
+type conditionalFormat struct {
+	format ConditionalFormat
+	cols   []int // 作用的列, 为空的时候是所有的列
+}
+
+// 设置表格主体的斑马纹, 行依次循环使用 styles
+func (table *Table) SetStripeStyles(styles ...CellStyle) {
+	for _, style := range styles {
+		checkCellStyle(style)
+	}
+	table.stripeStyles = styles
+}
+
+// 设置表头的样式
+func (table *Table) SetHeaderStyle(style CellStyle) {
+	checkCellStyle(style)
+	table.headerStyle = style
+}
+
+// 设置表尾的样式
+func (table *Table) SetFooterStyle(style CellStyle) {
+	checkCellStyle(style)
+	table.footerStyle = style
+}
+
+// 设置 row 行的样式
+func (table *Table) SetRowStyle(row int, style CellStyle) {
+	if row < 0 || row >= table.rows {
+		panic("invalid row: " + strconv.Itoa(row))
+	}
+	checkCellStyle(style)
+	if table.rowStyles == nil {
+		table.rowStyles = make(map[int]CellStyle)
+	}
+	table.rowStyles[row] = style
+}
+
+// 添加条件格式, cols 为空的时候作用于所有的列. min 和 max 在所有作用的列当中计算(热力图),
+// 需要按列计算的时候, 每一列单独添加
+func (table *Table) AddConditionalFormat(format ConditionalFormat, cols ...int) {
+	checkConditionalFormat(format)
+	for _, col := range cols {
+		if col < 0 || col >= table.cols {
+			panic("invalid column: " + strconv.Itoa(col))
+		}
+	}
+	table.formats = append(table.formats, conditionalFormat{format: format, cols: cols})
+}
+
+// 应用行样式和条件格式(只做一次)
+func (table *Table) applyStyles() {
+	if table.styled {
+		return
+	}
+	table.styled = true
+
+	if table.stripeStyles == nil && table.formats == nil && table.rowStyles == nil &&
+		table.headerStyle == (CellStyle{}) && table.footerStyle == (CellStyle{}) {
+		return
+	}
+
+	var (
+		styles = make([][]CellStyle, table.rows)
+		body   = table.rows - table.footerRows
+	)
+	for i := range styles {
+		styles[i] = make([]CellStyle, table.cols)
+		style, ok := table.rowStyles[i]
+		for j := range styles[i] {
+			switch {
+			case ok:
+				styles[i][j] = style
+			case i < table.headerRows:
+				styles[i][j] = table.headerStyle
+			case i >= body:
+				styles[i][j] = table.footerStyle
+			case table.stripeStyles != nil:
+				styles[i][j] = table.stripeStyles[(i-table.headerRows)%len(table.stripeStyles)]
+			}
+		}
+	}
+
+	for _, f := range table.formats {
+		var (
+			values   = make(map[*TableCell]float64)
+			min, max = math.Inf(1), math.Inf(-1)
+		)
+		for i := table.headerRows; i < body; i++ {
+			for j := 0; j < table.cols; j++ {
+				cell := table.cells[i][j]
+				if _, ok := table.rowStyles[i]; ok || cell == nil || cell.rowspan < 1 || !f.contains(j) {
+					continue
+				}
+				e, ok := cell.element.(StylableCell)
+				if !ok {
+					continue
+				}
+				if value, ok := parseNumber(e.GetContent()); ok {
+					values[cell] = value
+					min, max = math.Min(min, value), math.Max(max, value)
+				}
+			}
+		}
+
+		for cell, value := range values {
+			if style, ok := f.format.Format(value, min, max); ok {
+				styles[cell.row][cell.col] = styles[cell.row][cell.col].merge(style)
+			}
+		}
+	}
+
+	for i := 0; i < table.rows; i++ {
+		for j := 0; j < table.cols; j++ {
+			cell := table.cells[i][j]
+			if cell == nil || cell.rowspan < 1 || styles[i][j] == (CellStyle{}) {
+				continue
+			}
+			if e, ok := cell.element.(StylableCell); ok {
+				e.ApplyStyle(styles[i][j])
+			}
+		}
+	}
+}
+
+func (f conditionalFormat) contains(col int) bool {
+	if len(f.cols) == 0 {
+		return true
+	}
+	for _, c := range f.cols {
+		if c == col {
+			return true
+		}
+	}
+	return false
+}
+
+// 解析单元格的数值
+func parseNumber(s string) (float64, bool) {
+	s = strings.TrimSpace(s)
+	negative := false
+	if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+		negative, s = true, s[1:len(s)-1]
+	}
+	if strings.HasPrefix(s, "-") {
+		negative, s = !negative, s[1:]
+	}
+	for _, symbol := range []string{"$", "¥", "€", "£"} {
+		s = strings.TrimPrefix(s, symbol)
+	}
+	s = strings.TrimSuffix(strings.Replace(s, ",", "", -1), "%")
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, false
+	}
+	if negative {
+		value = -value
+	}
+	return value, true
+}
@@ -0,0 +1,1001 @@
+package gopdf
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/tiechui1994/gopdf/core"
+	"github.com/tiechui1994/gopdf/util"
+)
+
+/**
+SVG矢量图形, 以矢量的方式写入PDF(不会进行栅格化).
+
+支持的SVG子集:
+	元素: svg, g, path, rect, circle, ellipse, line, polyline, polygon, text(tspan), use
+	属性: transform, fill, stroke, stroke-width, fill-rule, font-family, font-size, text-anchor, style
+
+text 元素使用已经注册的字体(font-family), 字体没有注册的时候使用 SetFont() 设置的字体.
+渐变, 滤镜, 裁剪, 蒙版, CSS样式表 等不支持, 这些内容会被忽略.
+**/
+
+type SVGImage struct {
+	pdf           *core.Report
+	width, height float64 // 在PDF当中的尺寸
+	margin        core.Scope
+	font          core.Font // text 的默认字体
+
+	root    *svgNode
+	ids     map[string]*svgNode
+	viewBox [4]float64 // min-x, min-y, width, height
+
+	horizontalCentered bool   // 水平居中
+	rightAlign         bool   // 水平居右, 默认是居左
+	link               string // 链接
+
+	nodes int // 已经绘制的元素个数
+}
+
+// 绘制的元素个数的上限, 防止 use 的嵌套引用指数级展开
+const svgMaxNodes = 10000
+
+type svgNode struct {
+	name     string
+	attrs    map[string]string
+	children []*svgNode
+	text     string
+}
+
+// 继承的绘图属性
+type svgStyle struct {
+	fill, stroke string // "R,G,B", 空表示none
+	strokeWidth  float64
+	fillRule     string
+	fontFamily   string
+	fontSize     float64
+	textAnchor   string
+}
+
+// 仿射变换 [a b c d e f], x' = a*x + c*y + e, y' = b*x + d*y + f
+type svgMatrix [6]float64
+
+// 根据SVG的 width, height(或者viewBox) 计算尺寸, 超出内容区域的时候等比缩小
+func NewSVGImage(path string, pdf *core.Report) *SVGImage {
+	svg := newSVGImage(path, pdf)
+
+	contentWidth, contentHeight := pdf.GetContentWidthAndHeight()
+	if svg.width > contentWidth || svg.height > contentHeight {
+		scale := math.Min(contentWidth/svg.width, contentHeight/svg.height)
+		svg.width, svg.height = svg.width*scale, svg.height*scale
+	}
+
+	return svg
+}
+
+// 在 width*height 的区域内等比缩放
+func NewSVGImageWithWidthAndHeight(path string, width, height float64, pdf *core.Report) *SVGImage {
+	contentWidth, contentHeight := pdf.GetContentWidthAndHeight()
+	if width > contentWidth {
+		width = contentWidth
+	}
+	if height > contentHeight {
+		height = contentHeight
+	}
+
+	svg := newSVGImage(path, pdf)
+	if svg.height*width/svg.width > height {
+		width = svg.width * height / svg.height
+	} else {
+		height = svg.height * width / svg.width
+	}
+	svg.width, svg.height = width, height
+
+	return svg
+}
+
+func newSVGImage(path string, pdf *core.Report) *SVGImage {
+	fd, err := os.Open(path)
+	if err != nil {
+		panic("the path error")
+	}
+	defer fd.Close()
+
+	root, err := parseSVG(fd)
+	if err != nil {
+		panic(err)
+	}
+
+	svg := &SVGImage{
+		pdf:  pdf,
+		root: root,
+		ids:  make(map[string]*svgNode),
+	}
+	svg.indexNode(root)
+
+	// 尺寸: width, height 优先, 然后是 viewBox, 单位pt
+	width, height := parseSVGLength(root.attrs["width"]), parseSVGLength(root.attrs["height"])
+	if vb := parseSVGNumbers(root.attrs["viewBox"]); len(vb) == 4 && vb[2] > 0 && vb[3] > 0 {
+		copy(svg.viewBox[:], vb)
+	} else {
+		svg.viewBox = [4]float64{0, 0, width / 0.75, height / 0.75}
+	}
+
+	if width <= 0 && height <= 0 {
+		width, height = svg.viewBox[2]*0.75, svg.viewBox[3]*0.75
+	} else if width <= 0 {
+		width = height * svg.viewBox[2] / svg.viewBox[3]
+	} else if height <= 0 {
+		height = width * svg.viewBox[3] / svg.viewBox[2]
+	}
+
+	if width <= 0 || height <= 0 || svg.viewBox[2] <= 0 || svg.viewBox[3] <= 0 {
+		panic("the svg size invalid")
+	}
+
+	svg.width, svg.height = width, height
+	return svg
+}
+
+func (svg *SVGImage) indexNode(node *svgNode) {
+	if id, ok := node.attrs["id"]; ok {
+		svg.ids[id] = node
+	}
+	for _, child := range node.children {
+		svg.indexNode(child)
+	}
+}
+
+func (svg *SVGImage) SetMargin(margin core.Scope) *SVGImage {
+	margin.ReplaceMarign()
+	svg.margin = margin
+	return svg
+}
+
+// 设置 text 元素的默认字体
+func (svg *SVGImage) SetFont(font core.Font) *SVGImage {
+	svg.font = font
+	return svg
+}
+
+func (svg *SVGImage) HorizontalCentered() *SVGImage {
+	svg.horizontalCentered = true
+	svg.rightAlign = false
+	return svg
+}
+func (svg *SVGImage) RightAlign() *SVGImage {
+	svg.rightAlign = true
+	svg.horizontalCentered = false
+	return svg
+}
+
+// 设置链接, 整个图片是链接区域. link 的格式参考 Report.LinkArea
+func (svg *SVGImage) SetLink(link string) *SVGImage {
+	svg.link = link
+	return svg
+}
+
+func (svg *SVGImage) GetHeight() float64 {
+	return svg.height
+}
+func (svg *SVGImage) GetWidth() float64 {
+	return svg.width
+}
+
+// 自动换页
+func (svg *SVGImage) GenerateAtomicCell() error {
+	var (
+		sx, sy = svg.pdf.GetXY()
+	)
+
+	x, y := sx+svg.margin.Left, sy+svg.margin.Top
+	pageEndX, pageEndY := svg.pdf.GetPageEndXY()
+	if y < pageEndY && y+svg.height > pageEndY {
+		svg.pdf.AddNewPage(false)
+		sx, sy = svg.pdf.GetXY()
+		x, y = sx+svg.margin.Left, sy+svg.margin.Top
+	}
+
+	// 在内容区域的水平对齐
+	if svg.horizontalCentered && x+svg.width < pageEndX {
+		x += (pageEndX - x - svg.width) / 2
+	}
+	if svg.rightAlign && x+svg.width < pageEndX {
+		x = pageEndX - svg.width
+	}
+
+	// viewBox -> PDF, 等比缩放并居中(xMidYMid meet)
+	scale := math.Min(svg.width/svg.viewBox[2], svg.height/svg.viewBox[3])
+	dx := x + (svg.width-svg.viewBox[2]*scale)/2 - svg.viewBox[0]*scale
+	dy := y + (svg.height-svg.viewBox[3]*scale)/2 - svg.viewBox[1]*scale
+
+	style := svgStyle{fill: "0,0,0", strokeWidth: 1, fontSize: 16}
+	svg.nodes = 0
+	svg.drawNode(svg.root, svgMatrix{scale, 0, 0, scale, dx, dy}, style, 0)
+	if svg.link != "" {
+		svg.pdf.LinkArea(x, y, svg.width, svg.height, svg.link)
+	}
+
+	sx, _ = svg.pdf.GetPageStartXY()
+	svg.pdf.SetXY(sx, y+svg.height+svg.margin.Bottom)
+	return nil
+}
+
+func (svg *SVGImage) drawNode(node *svgNode, ctm svgMatrix, style svgStyle, depth int) {
+	if depth > 32 || svg.nodes >= svgMaxNodes || node.attrs["display"] == "none" || node.attrs["visibility"] == "hidden" {
+		return
+	}
+	svg.nodes++
+
+	style = style.inherit(node)
+	if transform, ok := node.attrs["transform"]; ok {
+		ctm = ctm.multiply(parseSVGTransform(transform))
+	}
+
+	switch node.name {
+	case "svg", "g", "a":
+		for _, child := range node.children {
+			svg.drawNode(child, ctm, style, depth+1)
+		}
+	case "use":
+		href := node.attrs["href"]
+		ref, ok := svg.ids[strings.TrimPrefix(href, "#")]
+		if !ok || !strings.HasPrefix(href, "#") {
+			return
+		}
+
+		ctm = ctm.multiply(svgMatrix{1, 0, 0, 1, svgAttr(node, "x"), svgAttr(node, "y")})
+		if ref.name == "symbol" {
+			for _, child := range ref.children {
+				svg.drawNode(child, ctm, style.inherit(ref), depth+1)
+			}
+			return
+		}
+		svg.drawNode(ref, ctm, style, depth+1)
+	case "text":
+		svg.drawText(node, ctm, style)
+	default:
+		path := svgShapePath(node)
+		if len(path) > 0 {
+			svg.drawPath(path, ctm, style)
+		}
+	}
+}
+
+func (svg *SVGImage) drawPath(path []svgSegment, ctm svgMatrix, style svgStyle) {
+	var (
+		buf    strings.Builder
+		format = func(x, y float64) string {
+			x, y = ctm.apply(x, y)
+			return util.Ftoa(x) + " " + util.Ftoa(y)
+		}
+	)
+
+	for _, seg := range path {
+		switch seg.op {
+		case 'M', 'L':
+			buf.WriteString(fmt.Sprintf("%c %v ", seg.op, format(seg.points[0], seg.points[1])))
+		case 'C':
+			buf.WriteString(fmt.Sprintf("C %v %v %v ", format(seg.points[0], seg.points[1]),
+				format(seg.points[2], seg.points[3]), format(seg.points[4], seg.points[5])))
+		case 'Z':
+			buf.WriteString("Z ")
+		}
+	}
+
+	var (
+		fill, stroke = style.fill, style.stroke
+		width        = style.strokeWidth * ctm.scale()
+		mode         string
+	)
+	if fill != "" {
+		mode = "F"
+	}
+	if stroke != "" && width > 0 {
+		mode = "D" + mode
+	} else {
+		stroke, width = "", 0
+	}
+	if mode == "" {
+		return
+	}
+	if fill != "" && style.fillRule == "evenodd" {
+		mode += "*"
+	}
+
+	svg.pdf.Path(strings.TrimSpace(buf.String()), mode, fill, stroke, width)
+}
+
+func (svg *SVGImage) drawText(node *svgNode, ctm svgMatrix, style svgStyle) {
+	content := strings.Join(strings.Fields(svgNodeText(node)), " ")
+	if content == "" || style.fill == "" {
+		return
+	}
+
+	font := svg.font
+	for _, family := range strings.Split(style.fontFamily, ",") {
+		family = strings.Trim(strings.TrimSpace(family), `"'`)
+		if family != "" && svg.pdf.HasFont(family) {
+			font = core.Font{Family: family, Size: font.Size}
+			break
+		}
+	}
+	if util.IsEmpty(font.Family) {
+		return
+	}
+
+	font.Size = int(math.Max(math.Round(style.fontSize*ctm.scale()), 1))
+	svg.pdf.Font(font.Family, font.Size, font.Style)
+	svg.pdf.SetFontWithStyle(font.Family, font.Style, font.Size)
+
+	x, y := ctm.apply(svgAttr(node, "x"), svgAttr(node, "y"))
+	switch style.textAnchor {
+	case "middle":
+		x -= svg.pdf.MeasureTextWidth(content) / 2
+	case "end":
+		x -= svg.pdf.MeasureTextWidth(content)
+	}
+
+	svg.pdf.TextColor(util.GetColorRGB(style.fill))
+	svg.pdf.Text(x, y, content)
+	svg.pdf.TextDefaultColor()
+}
+
+/********************************************************************************************************************/
+
+func parseSVG(r io.Reader) (*svgNode, error) {
+	var (
+		decoder = xml.NewDecoder(r)
+		stack   []*svgNode
+		root    *svgNode
+	)
+	decoder.Strict = false
+	decoder.Entity = xml.HTMLEntity
+
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t := token.(type) {
+		case xml.StartElement:
+			node := &svgNode{name: t.Name.Local, attrs: make(map[string]string)}
+			for _, attr := range t.Attr {
+				node.attrs[attr.Name.Local] = strings.TrimSpace(attr.Value)
+			}
+			if len(stack) > 0 {
+				parent := stack[len(stack)-1]
+				parent.children = append(parent.children, node)
+			} else if root == nil {
+				root = node
+			}
+			stack = append(stack, node)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case xml.CharData:
+			if len(stack) > 0 {
+				node := stack[len(stack)-1]
+				node.children = append(node.children, &svgNode{name: "#text", text: string(t)})
+			}
+		}
+	}
+
+	if root == nil || root.name != "svg" {
+		return nil, fmt.Errorf("invalid svg document")
+	}
+
+	return root, nil
+}
+
+// text 当中的全部文本(包括tspan)
+func svgNodeText(node *svgNode) string {
+	if node.name == "#text" {
+		return node.text
+	}
+
+	var texts []string
+	for _, child := range node.children {
+		texts = append(texts, svgNodeText(child))
+	}
+	return strings.Join(texts, "")
+}
+
+func (style svgStyle) inherit(node *svgNode) svgStyle {
+	attrs := make(map[string]string)
+	for k, v := range node.attrs {
+		attrs[k] = v
+	}
+	for _, decl := range strings.Split(node.attrs["style"], ";") {
+		kv := strings.SplitN(decl, ":", 2)
+		if len(kv) == 2 {
+			attrs[strings.TrimSpace(kv[0])] = strings.TrimSpace(kv[1])
+		}
+	}
+
+	if v, ok := attrs["fill"]; ok {
+		style.fill = parseSVGColor(v, style.fill)
+	}
+	if v, ok := attrs["stroke"]; ok {
+		style.stroke = parseSVGColor(v, style.stroke)
+	}
+	if v, ok := attrs["stroke-width"]; ok {
+		style.strokeWidth = parseSVGFloat(v)
+	}
+	if v, ok := attrs["fill-rule"]; ok {
+		style.fillRule = v
+	}
+	if v, ok := attrs["font-family"]; ok {
+		style.fontFamily = v
+	}
+	if v, ok := attrs["font-size"]; ok {
+		style.fontSize = parseSVGFloat(v)
+	}
+	if v, ok := attrs["text-anchor"]; ok {
+		style.textAnchor = v
+	}
+
+	return style
+}
+
+var svgColors = map[string]string{
+	"black":   "0,0,0",
+	"white":   "255,255,255",
+	"red":     "255,0,0",
+	"green":   "0,128,0",
+	"blue":    "0,0,255",
+	"yellow":  "255,255,0",
+	"gray":    "128,128,128",
+	"grey":    "128,128,128",
+	"silver":  "192,192,192",
+	"maroon":  "128,0,0",
+	"purple":  "128,0,128",
+	"fuchsia": "255,0,255",
+	"magenta": "255,0,255",
+	"lime":    "0,255,0",
+	"olive":   "128,128,0",
+	"navy":    "0,0,128",
+	"teal":    "0,128,128",
+	"aqua":    "0,255,255",
+	"cyan":    "0,255,255",
+	"orange":  "255,165,0",
+}
+
+// 颜色转换成 "R,G,B", none 返回空, 无法识别的颜色(比如渐变)返回空
+func parseSVGColor(value, inherit string) string {
+	value = strings.ToLower(strings.TrimSpace(value))
+	switch {
+	case value == "inherit" || value == "currentcolor":
+		return inherit
+	case value == "none" || value == "transparent":
+		return ""
+	case strings.HasPrefix(value, "#"):
+		hex := value[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if len(hex) != 6 {
+			return ""
+		}
+		rgb, err := strconv.ParseUint(hex, 16, 32)
+		if err != nil {
+			return ""
+		}
+		return fmt.Sprintf("%v,%v,%v", rgb>>16&0xFF, rgb>>8&0xFF, rgb&0xFF)
+	case strings.HasPrefix(value, "rgb(") && strings.HasSuffix(value, ")"):
+		parts := strings.Split(value[4:len(value)-1], ",")
+		if len(parts) != 3 {
+			return ""
+		}
+		rgb := make([]int, 3)
+		for i, part := range parts {
+			part = strings.TrimSpace(part)
+			if strings.HasSuffix(part, "%") {
+				rgb[i] = int(math.Round(parseSVGFloat(part[:len(part)-1]) * 2.55))
+			} else {
+				rgb[i] = int(parseSVGFloat(part))
+			}
+			rgb[i] = int(math.Max(0, math.Min(255, float64(rgb[i]))))
+		}
+		return fmt.Sprintf("%v,%v,%v", rgb[0], rgb[1], rgb[2])
+	}
+
+	return svgColors[value]
+}
+
+// 长度转换成pt, 不支持百分比
+func parseSVGLength(value string) float64 {
+	units := map[string]float64{"px": 0.75, "pt": 1, "pc": 12, "mm": 72 / 25.4, "cm": 72 / 2.54, "in": 72}
+	value = strings.TrimSpace(value)
+	if value == "" || strings.HasSuffix(value, "%") {
+		return 0
+	}
+	for unit, scale := range units {
+		if strings.HasSuffix(value, unit) {
+			return parseSVGFloat(strings.TrimSuffix(value, unit)) * scale
+		}
+	}
+	return parseSVGFloat(value) * 0.75
+}
+
+func parseSVGFloat(value string) float64 {
+	value = strings.TrimSuffix(strings.TrimSpace(value), "px")
+	f, _ := strconv.ParseFloat(value, 64)
+	return f
+}
+
+func parseSVGNumbers(value string) []float64 {
+	scanner := &svgScanner{data: value}
+	var numbers []float64
+	for {
+		scanner.skip()
+		if scanner.eof() {
+			return numbers
+		}
+		f, ok := scanner.number()
+		if !ok {
+			return numbers
+		}
+		numbers = append(numbers, f)
+	}
+}
+
+func svgAttr(node *svgNode, name string) float64 {
+	return parseSVGFloat(node.attrs[name])
+}
+
+/********************************************************************************************************************/
+
+func (m svgMatrix) multiply(n svgMatrix) svgMatrix {
+	return svgMatrix{
+		m[0]*n[0] + m[2]*n[1],
+		m[1]*n[0] + m[3]*n[1],
+		m[0]*n[2] + m[2]*n[3],
+		m[1]*n[2] + m[3]*n[3],
+		m[0]*n[4] + m[2]*n[5] + m[4],
+		m[1]*n[4] + m[3]*n[5] + m[5],
+	}
+}
+
+func (m svgMatrix) apply(x, y float64) (float64, float64) {
+	return m[0]*x + m[2]*y + m[4], m[1]*x + m[3]*y + m[5]
+}
+
+// 线宽, 字体大小的缩放比例
+func (m svgMatrix) scale() float64 {
+	return math.Sqrt(math.Abs(m[0]*m[3] - m[1]*m[2]))
+}
+
+// transform="translate(10,20) scale(2) rotate(45 10 10)"
+func parseSVGTransform(value string) svgMatrix {
+	m := svgMatrix{1, 0, 0, 1, 0, 0}
+	for _, item := range strings.Split(value, ")") {
+		kv := strings.SplitN(item, "(", 2)
+		if len(kv) != 2 {
+			continue
+		}
+
+		name, args := strings.Trim(strings.TrimSpace(kv[0]), ","), parseSVGNumbers(kv[1])
+		var t svgMatrix
+		switch {
+		case name == "matrix" && len(args) == 6:
+			copy(t[:], args)
+		case name == "translate" && len(args) == 1:
+			t = svgMatrix{1, 0, 0, 1, args[0], 0}
+		case name == "translate" && len(args) == 2:
+			t = svgMatrix{1, 0, 0, 1, args[0], args[1]}
+		case name == "scale" && len(args) == 1:
+			t = svgMatrix{args[0], 0, 0, args[0], 0, 0}
+		case name == "scale" && len(args) == 2:
+			t = svgMatrix{args[0], 0, 0, args[1], 0, 0}
+		case name == "rotate" && (len(args) == 1 || len(args) == 3):
+			a := args[0] * math.Pi / 180
+			t = svgMatrix{math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0}
+			if len(args) == 3 {
+				t = svgMatrix{1, 0, 0, 1, args[1], args[2]}.multiply(t).multiply(svgMatrix{1, 0, 0, 1, -args[1], -args[2]})
+			}
+		case name == "skewX" && len(args) == 1:
+			t = svgMatrix{1, 0, math.Tan(args[0] * math.Pi / 180), 1, 0, 0}
+		case name == "skewY" && len(args) == 1:
+			t = svgMatrix{1, math.Tan(args[0] * math.Pi / 180), 0, 1, 0, 0}
+		default:
+			continue
+		}
+		m = m.multiply(t)
+	}
+	return m
+}
+
+/********************************************************************************************************************/
+
+// 路径片段(绝对坐标): M, L(x,y), C(x1,y1,x2,y2,x,y), Z
+type svgSegment struct {
+	op     byte
+	points []float64
+}
+
+// 基本图形转换成路径
+func svgShapePath(node *svgNode) []svgSegment {
+	var (
+		attr = func(name string) float64 { return svgAttr(node, name) }
+		path []svgSegment
+	)
+
+	switch node.name {
+	case "path":
+		return parseSVGPath(node.attrs["d"])
+	case "rect":
+		x, y, w, h := attr("x"), attr("y"), attr("width"), attr("height")
+		rx, ry := attr("rx"), attr("ry")
+		if _, ok := node.attrs["ry"]; !ok {
+			ry = rx
+		}
+		if _, ok := node.attrs["rx"]; !ok {
+			rx = ry
+		}
+		rx, ry = math.Min(rx, w/2), math.Min(ry, h/2)
+		if w <= 0 || h <= 0 {
+			return nil
+		}
+		if rx <= 0 || ry <= 0 {
+			return []svgSegment{
+				{'M', []float64{x, y}}, {'L', []float64{x + w, y}}, {'L', []float64{x + w, y + h}},
+				{'L', []float64{x, y + h}}, {'Z', nil},
+			}
+		}
+		path = append(path, svgSegment{'M', []float64{x + rx, y}}, svgSegment{'L', []float64{x + w - rx, y}})
+		path = append(path, svgArc(x+w-rx, y, rx, ry, 0, false, true, x+w, y+ry)...)
+		path = append(path, svgSegment{'L', []float64{x + w, y + h - ry}})
+		path = append(path, svgArc(x+w, y+h-ry, rx, ry, 0, false, true, x+w-rx, y+h)...)
+		path = append(path, svgSegment{'L', []float64{x + rx, y + h}})
+		path = append(path, svgArc(x+rx, y+h, rx, ry, 0, false, true, x, y+h-ry)...)
+		path = append(path, svgSegment{'L', []float64{x, y + ry}})
+		path = append(path, svgArc(x, y+ry, rx, ry, 0, false, true, x+rx, y)...)
+		return append(path, svgSegment{'Z', nil})
+	case "circle", "ellipse":
+		cx, cy, rx, ry := attr("cx"), attr("cy"), attr("rx"), attr("ry")
+		if node.name == "circle" {
+			rx, ry = attr("r"), attr("r")
+		}
+		if rx <= 0 || ry <= 0 {
+			return nil
+		}
+		path = append(path, svgSegment{'M', []float64{cx + rx, cy}})
+		path = append(path, svgArc(cx+rx, cy, rx, ry, 0, false, true, cx-rx, cy)...)
+		path = append(path, svgArc(cx-rx, cy, rx, ry, 0, false, true, cx+rx, cy)...)
+		return append(path, svgSegment{'Z', nil})
+	case "line":
+		return []svgSegment{{'M', []float64{attr("x1"), attr("y1")}}, {'L', []float64{attr("x2"), attr("y2")}}}
+	case "polyline", "polygon":
+		points := parseSVGNumbers(node.attrs["points"])
+		for i := 0; i+1 < len(points); i += 2 {
+			op := byte('L')
+			if i == 0 {
+				op = 'M'
+			}
+			path = append(path, svgSegment{op, []float64{points[i], points[i+1]}})
+		}
+		if node.name == "polygon" && len(path) > 0 {
+			path = append(path, svgSegment{'Z', nil})
+		}
+		return path
+	}
+
+	return nil
+}
+
+// 路径数据解析, 所有的命令转换成 M, L, C, Z
+func parseSVGPath(d string) []svgSegment {
+	var (
+		scanner = &svgScanner{data: d}
+		path    []svgSegment
+		cmd     byte
+		x, y    float64 // 当前点
+		sx, sy  float64 // 子路径开始点
+		cx, cy  float64 // 上一个控制点(S, T使用)
+		lastCmd byte
+		numbers = func(n int) ([]float64, bool) {
+			values := make([]float64, n)
+			for i := range values {
+				scanner.skip()
+				f, ok := scanner.number()
+				if !ok {
+					return nil, false
+				}
+				values[i] = f
+			}
+			return values, true
+		}
+	)
+
+	for {
+		scanner.skip()
+		if scanner.eof() {
+			break
+		}
+
+		if c := scanner.peek(); strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+			scanner.pos++
+		} else if cmd == 0 {
+			break
+		}
+
+		relative := cmd >= 'a'
+		ox, oy := 0.0, 0.0
+		if relative {
+			ox, oy = x, y
+		}
+
+		switch cmd {
+		case 'M', 'm':
+			v, ok := numbers(2)
+			if !ok {
+				return path
+			}
+			x, y = ox+v[0], oy+v[1]
+			sx, sy = x, y
+			path = append(path, svgSegment{'M', []float64{x, y}})
+			// 后续的坐标对作为 L 处理
+			cmd = 'L'
+			if relative {
+				cmd = 'l'
+			}
+		case 'L', 'l':
+			v, ok := numbers(2)
+			if !ok {
+				return path
+			}
+			x, y = ox+v[0], oy+v[1]
+			path = append(path, svgSegment{'L', []float64{x, y}})
+		case 'H', 'h':
+			v, ok := numbers(1)
+			if !ok {
+				return path
+			}
+			x = ox + v[0]
+			path = append(path, svgSegment{'L', []float64{x, y}})
+		case 'V', 'v':
+			v, ok := numbers(1)
+			if !ok {
+				return path
+			}
+			y = oy + v[0]
+			path = append(path, svgSegment{'L', []float64{x, y}})
+		case 'C', 'c':
+			v, ok := numbers(6)
+			if !ok {
+				return path
+			}
+			points := []float64{ox + v[0], oy + v[1], ox + v[2], oy + v[3], ox + v[4], oy + v[5]}
+			path = append(path, svgSegment{'C', points})
+			cx, cy, x, y = points[2], points[3], points[4], points[5]
+		case 'S', 's':
+			v, ok := numbers(4)
+			if !ok {
+				return path
+			}
+			x1, y1 := x, y
+			if strings.IndexByte("CcSs", lastCmd) >= 0 {
+				x1, y1 = 2*x-cx, 2*y-cy
+			}
+			points := []float64{x1, y1, ox + v[0], oy + v[1], ox + v[2], oy + v[3]}
+			path = append(path, svgSegment{'C', points})
+			cx, cy, x, y = points[2], points[3], points[4], points[5]
+		case 'Q', 'q', 'T', 't':
+			var qx, qy, ex, ey float64
+			if cmd == 'Q' || cmd == 'q' {
+				v, ok := numbers(4)
+				if !ok {
+					return path
+				}
+				qx, qy, ex, ey = ox+v[0], oy+v[1], ox+v[2], oy+v[3]
+			} else {
+				v, ok := numbers(2)
+				if !ok {
+					return path
+				}
+				qx, qy = x, y
+				if strings.IndexByte("QqTt", lastCmd) >= 0 {
+					qx, qy = 2*x-cx, 2*y-cy
+				}
+				ex, ey = ox+v[0], oy+v[1]
+			}
+			// 二次贝塞尔曲线 -> 三次贝塞尔曲线
+			path = append(path, svgSegment{'C', []float64{
+				x + 2.0/3.0*(qx-x), y + 2.0/3.0*(qy-y),
+				ex + 2.0/3.0*(qx-ex), ey + 2.0/3.0*(qy-ey),
+				ex, ey,
+			}})
+			cx, cy, x, y = qx, qy, ex, ey
+		case 'A', 'a':
+			v, ok := numbers(3)
+			if !ok {
+				return path
+			}
+			scanner.skip()
+			large, ok1 := scanner.flag()
+			scanner.skip()
+			sweep, ok2 := scanner.flag()
+			end, ok3 := numbers(2)
+			if !ok1 || !ok2 || !ok3 {
+				return path
+			}
+			ex, ey := ox+end[0], oy+end[1]
+			path = append(path, svgArc(x, y, v[0], v[1], v[2], large, sweep, ex, ey)...)
+			x, y = ex, ey
+		case 'Z', 'z':
+			path = append(path, svgSegment{'Z', nil})
+			x, y = sx, sy
+			// Z 没有参数, 后面不是命令的时候是错误, 停止解析
+			lastCmd, cmd = cmd, 0
+			continue
+		}
+
+		lastCmd = cmd
+	}
+
+	return path
+}
+
+// 椭圆弧 -> 三次贝塞尔曲线(SVG规范 F.6.5, 端点参数 -> 中心参数)
+func svgArc(x1, y1, rx, ry, angle float64, large, sweep bool, x2, y2 float64) []svgSegment {
+	if x1 == x2 && y1 == y2 {
+		return nil
+	}
+
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	line := []svgSegment{{'L', []float64{x2, y2}}}
+	if rx == 0 || ry == 0 || math.IsNaN(rx+ry+angle) || math.IsInf(rx+ry+angle, 0) {
+		return line
+	}
+
+	phi := angle * math.Pi / 180
+	cos, sin := math.Cos(phi), math.Sin(phi)
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p, y1p := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// 半径修正
+	if lambda := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp, cyp := coef*rx*y1p/ry, -coef*ry*x1p/rx
+	cx, cy := cos*cxp-sin*cyp+(x1+x2)/2, sin*cxp+cos*cyp+(y1+y2)/2
+
+	vecAngle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	theta := vecAngle(1, 0, (x1p-cxp)/rx, (y1p-cyp)/ry)
+	delta := vecAngle((x1p-cxp)/rx, (y1p-cyp)/ry, (-x1p-cxp)/rx, (-y1p-cyp)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	// 每段不超过90度
+	n := int(math.Ceil(math.Abs(delta) / (math.Pi / 2)))
+	if n <= 0 || math.IsNaN(cx+cy) {
+		return line
+	}
+	step := delta / float64(n)
+	k := 4.0 / 3.0 * math.Tan(step/4)
+
+	var (
+		path  []svgSegment
+		point = func(t float64) (float64, float64) {
+			ex, ey := rx*math.Cos(t), ry*math.Sin(t)
+			return cos*ex - sin*ey + cx, sin*ex + cos*ey + cy
+		}
+		tangent = func(t float64) (float64, float64) {
+			ex, ey := -rx*math.Sin(t), ry*math.Cos(t)
+			return cos*ex - sin*ey, sin*ex + cos*ey
+		}
+	)
+	for i := 0; i < n; i++ {
+		t1, t2 := theta+float64(i)*step, theta+float64(i+1)*step
+		px1, py1 := point(t1)
+		px2, py2 := point(t2)
+		dx1, dy1 := tangent(t1)
+		dx2, dy2 := tangent(t2)
+		path = append(path, svgSegment{'C', []float64{
+			px1 + k*dx1, py1 + k*dy1,
+			px2 - k*dx2, py2 - k*dy2,
+			px2, py2,
+		}})
+	}
+	path[len(path)-1].points[4], path[len(path)-1].points[5] = x2, y2
+
+	return path
+}
+
+// 路径数据, 数字列表的扫描
+type svgScanner struct {
+	data string
+	pos  int
+}
+
+func (s *svgScanner) eof() bool {
+	return s.pos >= len(s.data)
+}
+
+func (s *svgScanner) peek() byte {
+	return s.data[s.pos]
+}
+
+// 跳过空白和逗号
+func (s *svgScanner) skip() {
+	for !s.eof() && strings.IndexByte(" \t\r\n,", s.peek()) >= 0 {
+		s.pos++
+	}
+}
+
+// 弧线的标记位, 只有一个字符 0 或者 1, 后面可以直接跟数字
+func (s *svgScanner) flag() (bool, bool) {
+	if s.eof() || (s.peek() != '0' && s.peek() != '1') {
+		return false, false
+	}
+	s.pos++
+	return s.data[s.pos-1] == '1', true
+}
+
+func (s *svgScanner) number() (float64, bool) {
+	start := s.pos
+	if !s.eof() && (s.peek() == '+' || s.peek() == '-') {
+		s.pos++
+	}
+
+	digits, dot := 0, false
+	for !s.eof() {
+		c := s.peek()
+		if c >= '0' && c <= '9' {
+			digits++
+		} else if c == '.' && !dot {
+			dot = true
+		} else {
+			break
+		}
+		s.pos++
+	}
+
+	// 指数部分
+	if digits > 0 && !s.eof() && (s.peek() == 'e' || s.peek() == 'E') {
+		save := s.pos
+		s.pos++
+		if !s.eof() && (s.peek() == '+' || s.peek() == '-') {
+			s.pos++
+		}
+		exp := 0
+		for !s.eof() && s.peek() >= '0' && s.peek() <= '9' {
+			s.pos++
+			exp++
+		}
+		if exp == 0 {
+			s.pos = save
+		}
+	}
+
+	if digits == 0 {
+		s.pos = start
+		return 0, false
+	}
+
+	f, err := strconv.ParseFloat(s.data[start:s.pos], 64)
+	return f, err == nil
+}
@@ -0,0 +1,157 @@
+package gopdf
+
+import (
+	"fmt"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tiechui1994/gopdf/core"
+)
+
+const SVG_MD = "MPBOLD"
+
+const svgLogo = `<?xml version="1.0" encoding="UTF-8"?>
+<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="200" height="100" viewBox="0 0 200 100">
+  <defs>
+    <circle id="dot" r="5" fill="red"/>
+  </defs>
+  <rect x="1" y="1" width="198" height="98" rx="10" fill="#eee" stroke="#333" stroke-width="2"/>
+  <g transform="translate(20,20) scale(0.5)" fill="rgb(0, 128, 255)">
+    <path d="M0 0 h40 v40 h-40 z M10 10 l20 0 0 20 -20 0z" fill-rule="evenodd"/>
+    <path d="M60,0 Q80,40 100,0 T140,0 A20 20 0 0 1 180 0 c10 10 20 10 30 0 s20 -10 30 0"
+          fill="none" stroke="black" stroke-width="3"/>
+  </g>
+  <ellipse cx="150" cy="60" rx="30" ry="15" style="fill:none;stroke:green"/>
+  <polygon points="10,90 30,70 50,90"/>
+  <line x1="60" y1="90" x2="120" y2="90" stroke="navy"/>
+  <use xlink:href="#dot" x="180" y="20"/>
+  <text x="100" y="50" font-family="MPBOLD" font-size="12" text-anchor="middle" fill="#900">Hello SVG</text>
+</svg>`
+
+func SVGReport(path string) *core.Report {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: SVG_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(func(report *core.Report) {
+		svg := NewSVGImageWithWidthAndHeight(path, 300, 300, report)
+		svg.SetFont(core.Font{Family: SVG_MD, Size: 10})
+		svg.HorizontalCentered().GenerateAtomicCell()
+	}), core.Detail)
+
+	r.Execute("svg_test.pdf")
+	return r
+}
+
+func TestSVGImage(t *testing.T) {
+	dir, err := ioutil.TempDir("", "svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "logo.svg")
+	ioutil.WriteFile(path, []byte(svgLogo), 0644)
+
+	r := SVGReport(path)
+
+	var paths, texts []string
+	for _, cell := range *r.GetAtomicCells() {
+		if strings.HasPrefix(cell, "PA|") {
+			paths = append(paths, cell)
+		}
+		if strings.HasPrefix(cell, "CT|") {
+			texts = append(texts, cell)
+		}
+	}
+
+	// rect, 2 path, ellipse, polygon, line, use
+	if len(paths) != 7 {
+		t.Fatalf("expect 7 paths, got %v: %v", len(paths), paths)
+	}
+	if !strings.HasPrefix(paths[0], "PA|DF|238,238,238|51,51,51|") {
+		t.Errorf("rect: %v", paths[0])
+	}
+	if !strings.HasPrefix(paths[1], "PA|F*|0,128,255||") {
+		t.Errorf("evenodd path: %v", paths[1])
+	}
+	if !strings.HasPrefix(paths[2], "PA|D||0,0,0|") {
+		t.Errorf("stroke path: %v", paths[2])
+	}
+	if len(texts) != 1 || !strings.HasSuffix(texts[0], "|Hello SVG") {
+		t.Errorf("text: %v", texts)
+	}
+}
+
+func TestSVGPathClose(t *testing.T) {
+	// Z 后面的数字是错误, 停止解析
+	path := parseSVGPath("M0 0 L10 10 Z 5 5")
+	if len(path) != 3 || path[2].op != 'Z' {
+		t.Errorf("expect M L Z, got %v", path)
+	}
+
+	path = parseSVGPath("M0 0 h10 z m5 5 v10 Z")
+	if len(path) != 6 || path[3].op != 'M' || path[3].points[0] != 5 || path[3].points[1] != 5 {
+		t.Errorf("expect two subpaths, got %v", path)
+	}
+}
+
+func TestSVGArcDegenerate(t *testing.T) {
+	cases := [][4]float64{
+		{math.NaN(), 10, 0, 0},
+		{10, math.Inf(1), 0, 0},
+		{10, 10, math.NaN(), 0},
+		{1e-300, 1e-300, 0, 0},
+	}
+	for i, c := range cases {
+		path := svgArc(0, 0, c[0], c[1], c[2], false, true, 10, 10)
+		if len(path) == 0 {
+			t.Errorf("case %v: expect the arc to end at (10, 10), got nothing", i)
+			continue
+		}
+		if last := path[len(path)-1].points; last[len(last)-2] != 10 || last[len(last)-1] != 10 {
+			t.Errorf("case %v: expect the arc to end at (10, 10), got %v", i, path)
+		}
+	}
+}
+
+// use 的嵌套引用, 绘制的元素个数有上限
+func TestSVGUseLimit(t *testing.T) {
+	var buf strings.Builder
+	buf.WriteString(`<svg xmlns="http://www.w3.org/2000/svg" xmlns:xlink="http://www.w3.org/1999/xlink" width="100" height="100"><defs>`)
+	buf.WriteString(`<rect id="g0" width="1" height="1"/>`)
+	for i := 1; i <= 12; i++ {
+		fmt.Fprintf(&buf, `<g id="g%d">`, i)
+		for k := 0; k < 4; k++ {
+			fmt.Fprintf(&buf, `<use xlink:href="#g%d"/>`, i-1)
+		}
+		buf.WriteString(`</g>`)
+	}
+	buf.WriteString(`</defs><use xlink:href="#g12"/></svg>`)
+
+	dir, err := ioutil.TempDir("", "svg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "bomb.svg")
+	ioutil.WriteFile(path, []byte(buf.String()), 0644)
+
+	var paths int
+	for _, cell := range *SVGReport(path).GetAtomicCells() {
+		if strings.HasPrefix(cell, "PA|") {
+			paths++
+		}
+	}
+	if paths == 0 || paths > svgMaxNodes {
+		t.Errorf("expect at most %v paths, got %v", svgMaxNodes, paths)
+	}
+}
@@ -43,7 +43,12 @@ core.Cell接口的实现类可以生成自定义的单元格. 默认的一个实
 空白cell: 比如(0,1), (1,0) 等, 非用户写入单元格内容的开始位置, 辅助作用.
 非空白cell: 比如(0,0), (0,2), (3,4)等, 用户要写入单元格内容的开始位置(table当中)
 
-注: 目前在table的分页当中, 背景颜色和线条存在bug.
+表头和表尾: 前 n 行是表头, 在每一页的顶部重复写入; 后 n 行是表尾, 在每一页的底部重复写入.
+表头和表尾不参与分页, 其单元格元素必须实现 CopyableCell, 每一页写入的是元素的副本.
+
+分页: 按行计算每一页可以写入的内容. 行高是当前行单元格剩余内容的最大高度, 跨行单元格不足的高度由其最后一行补齐.
+跨页的行(包括跨行的单元格)在页面的底部拆分, 单元格在每一页都是一个完整的片段: 背景颜色填满片段, 四条边框
+在拆分的两侧都会画出, 剩余的内容在下一页继续写入. 跨页的行没有任何单元格可以写入的时候, 整行移到下一页.
 **/
 
 // 构建表格
@@ -51,8 +56,10 @@ type Table struct {
 	pdf           *core.Report
 	rows, cols    int // 行数,列数
 	width, height float64
+	baseWidth     float64        // 设置的宽度, 百分比列宽和剩余宽度的基准
 	colwidths     []float64      // 列宽百分比: 应加起来为1
-	rowheights    []float64      // 保存行高
+	columns       []column       // 列宽的设置, 参考 SetColumnWidths
+	rowheights    []float64      // 行的最小高度, 列分页的时候各个部分保持一致
 	cells         [][]*TableCell // 单元格
 
 	lineHeight float64    // 默认行高
@@ -61,8 +68,42 @@ type Table struct {
 	nextrow, nextcol int // 下一个位置
 
 	tableCheck bool      // table 完整性检查
-	cachedRow  []float64 // 缓存行
+	x          float64   // 表格的左侧
 	cachedCol  []float64 // 缓存列
+
+	start, end int  // 还没有写完的行 [start, end)
+	split      bool // start 行已经写入了部分内容
+
+	outerBorder, innerBorder Border // 外边框, 内部的网格线
+
+	stripeStyles             []CellStyle         // 斑马纹
+	headerStyle, footerStyle CellStyle           // 表头, 表尾的样式
+	formats                  []conditionalFormat // 条件格式
+	rowStyles                map[int]CellStyle   // 行样式
+	styled                   bool                // 样式已经应用
+	prepared                 bool                // 已经完成写入之前的准备
+
+	keepTogether, keepWithNext    map[int]bool // 分页控制, 参考 keep.go
+	minLinesBefore, minLinesAfter int          // 拆分的单元格在分页之前, 之后至少保留的行数
+
+	paging      bool         // 列分页, 参考 paging.go
+	keyCols     map[int]bool // 每一部分重复的列
+	labelFormat string       // 每一部分的标签格式
+	labelFont   core.Font    // 标签的字体
+	label       string       // 当前部分的标签
+	labelHeight float64      // 标签的高度
+
+	headerRows, footerRows int            // 表头, 表尾的行数
+	header, footer         [][]*TableCell // 表头, 表尾, 每一页重复写入
+	headerHeight           float64        // 表头的高度
+	footerHeight           float64        // 表尾的高度, 每一页都需要预留
+	repeat                 bool           // 表头或表尾的副本, 不分页
+}
+
+// 可以复制的单元格元素. 表头和表尾在每一页重复写入, 每次写入的都是元素的副本
+type CopyableCell interface {
+	core.Cell
+	CopyCell() core.Cell
 }
 
 type TableCell struct {
@@ -70,10 +111,8 @@ type TableCell struct {
 	row, col         int    // 位置
 	rowspan, colspan int    // 单元格大小
 
-	element    core.Cell // 单元格元素
-	minheight  float64   // 当前最小单元格的高度, rowspan=1, 辅助计算
-	height     float64   // 当前表格单元真实高度, rowspan >= 1, 实际计算垂直线高度的时候使用
-	cellwrited int       // 写入的行数
+	element core.Cell // 单元格元素
+	borders [4]Border // 左, 上, 右, 下的边框
 }
 
 func (cell *TableCell) SetElement(e core.Cell) *TableCell {
@@ -88,11 +127,12 @@ func NewTable(cols, rows int, width, lineHeight float64, pdf *core.Report) *Tabl
 	}
 
 	t := &Table{
-		pdf:    pdf,
-		rows:   rows,
-		cols:   cols,
-		width:  width,
-		height: 0,
+		pdf:       pdf,
+		rows:      rows,
+		cols:      cols,
+		width:     width,
+		baseWidth: width,
+		height:    0,
 
 		nextcol: 0,
 		nextrow: 0,
@@ -100,6 +140,9 @@ func NewTable(cols, rows int, width, lineHeight float64, pdf *core.Report) *Tabl
 		lineHeight: lineHeight,
 		colwidths:  []float64{},
 		rowheights: []float64{},
+
+		outerBorder: Border{Style: BORDER_SOLID},
+		innerBorder: Border{Style: BORDER_SOLID},
 	}
 
 	for i := 0; i < cols; i++ {
@@ -124,13 +167,11 @@ func (table *Table) NewCell() *TableCell {
 	}
 
 	cell := &TableCell{
-		row:       row,
-		col:       col,
-		rowspan:   1,
-		colspan:   1,
-		table:     table,
-		height:    table.lineHeight,
-		minheight: table.lineHeight,
+		row:     row,
+		col:     col,
+		rowspan: 1,
+		colspan: 1,
+		table:   table,
 	}
 
 	table.cells[row][col] = cell
@@ -163,13 +204,11 @@ func (table *Table) NewCellByRange(w, h int) *TableCell {
 	}
 
 	cell := &TableCell{
-		row:       row,
-		col:       col,
-		rowspan:   rowspan,
-		colspan:   colspan,
-		table:     table,
-		height:    table.lineHeight * float64(h),
-		minheight: table.lineHeight,
+		row:     row,
+		col:     col,
+		rowspan: rowspan,
+		colspan: colspan,
+		table:   table,
 	}
 
 	table.cells[row][col] = cell
@@ -183,13 +222,11 @@ func (table *Table) NewCellByRange(w, h int) *TableCell {
 
 		for ; j < colspan; j++ {
 			table.cells[row+i][col+j] = &TableCell{
-				row:       row + i,
-				col:       col + j,
-				rowspan:   -row,
-				colspan:   -col,
-				table:     table,
-				height:    table.lineHeight,
-				minheight: table.lineHeight,
+				row:     row + i,
+				col:     col + j,
+				rowspan: -row,
+				colspan: -col,
+				table:   table,
 			}
 		}
 	}
@@ -304,724 +341,378 @@ func (table *Table) SetMargin(margin core.Scope) {
 	table.margin = margin
 }
 
-/********************************************************************************************************************/
-
-func (table *Table) GenerateAtomicCell() error {
-	var (
-		sx, sy        = table.pdf.GetXY() // 基准坐标
-		_, pageEndY   = table.pdf.GetPageEndXY()
-		x1, y1, _, y2 float64 // 当前位置
-	)
-
-	// 重新计算行高, 并且缓存每个位置的开始坐标
-	table.resetCellHeight()
-	table.cachedPoints(sx, sy)
-
-	for i := 0; i < table.rows; i++ {
-		for j := 0; j < table.cols; j++ {
-			// 这是遍历的每一个cell的rowspan是1
-			_, y1, _, y2 = table.getVLinePosition(sx, sy, j, i)
-			if table.cells[i][j].rowspan > 1 {
-				y2 = y1 + table.cells[i][j].minheight
-			}
-
-			// 换页
-			if y1 < pageEndY && y2 > pageEndY {
-				cell := table.cells[i][j]
-				if cell.row == 0 && cell.col == 0 && !table.checkFirstRowCanWrite(sx, sy) {
-					table.pdf.AddNewPage(false)
-					table.margin.Top = 0
-					table.pdf.SetXY(table.pdf.GetPageStartXY())
-					return table.GenerateAtomicCell()
-				}
-				// 写完剩余的内容
-				table.writeCurrentPageRestCells(i, j, sx, sy)
-
-				// 画当前页面边框线
-				table.drawPageLines(sx, sy)
-
-				// 重置tableCells
-				table.resetTableCells()
-
-				// 相关动态变量重置
-				table.pdf.AddNewPage(false)
-				table.margin.Top = 0
-				table.rows = len(table.cells)
-				table.pdf.SetXY(table.pdf.GetPageStartXY())
-
-				table.pdf.LineType("straight", 0.1)
-
-				if table.rows == 0 {
-					return nil
-				}
-
-				return table.GenerateAtomicCell()
-			}
-
-			if table.cells[i][j].element == nil {
-				continue
-			}
-
-			x1, y1, _, y2 = table.getVLinePosition(sx, sy, j, i) // 真实的垂直线
-
-			// 当前cell高度跨页
-			if y1 < pageEndY && y2 > pageEndY {
-				table.writePartialPageCell(i, j, sx, sy) // 部分写入
-			}
-
-			// 当前celll没有跨页
-			if y1 < pageEndY && y2 < pageEndY {
-				table.writeCurrentPageCell(i, j, sx, sy)
-			}
-		}
+// 设置表头的行数, 表头在每一页的顶部重复写入
+func (table *Table) SetHeaderRows(rows int) {
+	if rows < 0 || rows+table.footerRows > table.rows {
+		panic("invalid header rows")
 	}
-
-	// 最后一个页面的最后部分
-	table.drawLastPageLines(sx, sy)
-
-	// 重置当前的坐标(非常重要)
-	height := table.getLastPageHeight()
-	_, y1, _, y2 = table.getVLinePosition(sx, sy, 0, 0)
-	x1, _ = table.pdf.GetPageStartXY()
-	table.pdf.SetXY(x1, y1+height+table.margin.Top+table.margin.Bottom)
-
-	return nil
+	table.headerRows = rows
 }
 
-func (table *Table) checkFirstRowCanWrite(sx, sy float64) (ok bool) {
-	var (
-		_, pageEndY = table.pdf.GetPageEndXY()
-	)
-
-	row := 0
-	for col := 0; col < table.cols; col++ {
-		cell := table.cells[row][col]
-		_, y, _, _ := table.getHLinePosition(sx, sy, col, 0)
+// 设置表尾的行数, 表尾在每一页的底部重复写入
+func (table *Table) SetFooterRows(rows int) {
+	if rows < 0 || rows+table.headerRows > table.rows {
+		panic("invalid footer rows")
+	}
+	table.footerRows = rows
+}
 
-		if cell.rowspan < 1 {
-			continue
-		}
+/********************************************************************************************************************/
 
-		if cell.rowspan >= 1 {
-			wn, _ := cell.element.TryGenerateAtomicCell(pageEndY - y)
-			if wn > 0 {
-				ok = true
-				return ok
-			}
-		}
-	}
+// 表格在一页当中的布局, 当前页面写入的行是 [start, end)
+type tableLayout struct {
+	start, end int
+	tops       []float64 // 每一行的开始位置
+	heights    []float64 // 每一行在当前页面的高度
+	bottom     float64   // 表格在当前页面的底部
+	split      bool      // 最后一行被拆分, 剩余的部分在下一页写入
+	continued  bool      // 第一行是上一页拆分的行
+}
 
-	return ok
+// 单元格在一页当中的片段
+type tableFragment struct {
+	cell        *TableCell
+	top, bottom float64
 }
 
-// row,col 定位cell, sx,sy是table基准坐标
-func (table *Table) writeCurrentPageCell(row, col int, sx, sy float64) {
-	var (
-		x1, y1, _, y2 float64
-		_, pageEndY   = table.pdf.GetPageEndXY()
-		cell          = table.cells[row][col]
-	)
+func (table *Table) GenerateAtomicCell() error {
+	// 列分页
+	if table.paging {
+		table.checkTableConstraint()
+		table.applyStyles()
+		table.layoutColumns()
 
-	x1, y1, _, y2 = table.getVLinePosition(sx, sy, col, row)
-	cell.table.pdf.SetXY(x1, y1)
-
-	if cell.element != nil {
-		// 检查当前Cell下面的Cell能否写入(下一个Cell跨页), 如果不能写入, 需要修正写入的高度值[颜色控制]
-		i, j := cell.row+cell.rowspan-table.cells[0][0].row, cell.col-table.cells[0][0].col
-		if i < len(table.cells) {
-			_, y3, _, y4 := table.getVLinePosition(sx, sy, j, i)
-			if y3 < pageEndY && y4 >= pageEndY {
-				if !table.checkNextCellCanWrite(sx, sy, row, col) {
-					y2 = pageEndY
-				}
-			}
+		x, _ := table.pdf.GetXY()
+		endX, _ := table.pdf.GetPageEndXY()
+		if strips := table.columnStrips(endX - x - table.margin.Left - table.margin.Right); strips != nil {
+			return table.generateStrips(strips)
 		}
+	}
 
-		if cell.element.GetHeight() == 0 {
-			cell.element.GenerateAtomicCell(y2 - y1)
-			cell.cellwrited = cell.rowspan
-			return
-		}
+	table.prepare()
 
-		cell.element.GenerateAtomicCell(y2 - y1)
-		cell.cellwrited = cell.rowspan
-	}
-}
-func (table *Table) writePartialPageCell(row, col int, sx, sy float64) {
 	var (
-		x1, y1      float64
-		_, pageEndY = table.pdf.GetPageEndXY()
-		cell        = table.cells[row][col]
+		x, y           = table.pdf.GetXY() // 基准坐标
+		startX, startY = table.pdf.GetPageStartXY()
 	)
+	y += table.margin.Top
 
-	x1, y1, _, _ = table.getVLinePosition(sx, sy, col, row) // 垂直线
-	cell.table.pdf.SetXY(x1, y1)
-
-	if cell.element != nil {
-		if cell.element.GetHeight() == 0 {
-			cell.element.GenerateAtomicCell(pageEndY - y1)
-			cell.cellwrited = cell.rowspan
-			return
-		}
-
-		// 尝试写入(跨页的Cell), 写不进去就不再写
-		wn, _ := cell.element.TryGenerateAtomicCell(pageEndY - y1)
-		if wn == 0 {
-			cell.cellwrited = 0
-			return
-		}
-
-		// 真正的写入
-		wn, _, _ = cell.element.GenerateAtomicCell(pageEndY - y1)
+	for {
+		// 页面的顶部至少写入一行, 避免死循环
+		bottom, ok := table.writeFragment(x, y, table.getPageEndY(), y-startY < 0.01)
 
-		// 设置 cellwrited 的值
-		if wn > 0 && cell.element.GetHeight() == 0 {
-			cell.cellwrited = cell.rowspan
+		// 重置当前的坐标(非常重要)
+		if ok && table.start >= table.end {
+			table.pdf.SetXY(startX, bottom+table.margin.Bottom)
+			return nil
 		}
 
-		if wn > 0 && cell.element.GetHeight() != 0 {
-			if cell.rowspan == 1 {
-				cell.cellwrited = 0
-			}
-
-			if cell.rowspan > 1 {
-				count := 0
-				for i := row; i < row+cell.rowspan; i++ {
-					_, y1, _, y2 := table.getVLinePosition(sx, sy, col, i)
-					if table.cells[i][col].element != nil {
-						y2 = y1 + table.cells[i][col].minheight
-					}
-
-					if y1 < pageEndY && y2 <= pageEndY {
-						count++
-					}
-					if y1 > pageEndY || y2 > pageEndY {
-						break
-					}
-				}
-
-				cell.cellwrited = count
-			}
-		}
+		// 当前页面写不下任何内容或者还有剩余的行, 换页
+		table.pdf.AddNewPage(false)
+		y = startY
 	}
 }
 
-// 当前页面的剩余内容
-func (table *Table) writeCurrentPageRestCells(row, col int, sx, sy float64) {
-	for i := col; i < table.cols; i++ {
-		table.writePartialPageCell(row, i, sx, sy)
+// 写入之前的准备(只做一次): 应用样式, 计算列宽, 分离表头和表尾
+func (table *Table) prepare() {
+	if table.prepared {
+		return
 	}
-}
+	table.prepared = true
 
-// 检查下一个Cell是否可以写入(当前的Cell必须是非空格Cell)
-func (table *Table) checkNextCellCanWrite(sx, sy float64, row, col int) bool {
-	var (
-		canwrite    bool
-		cells       = table.cells
-		_, pageEndY = table.pdf.GetPageEndXY()
-	)
+	table.checkTableConstraint()
+	table.applyStyles()
+	table.layoutColumns()
+	table.splitRepeatRows()
 
-	if cells[row][col].rowspan <= 0 {
-		return canwrite
+	if table.header != nil {
+		table.headerHeight = table.repeatTable(table.header).getRowsHeight()
 	}
-
-	// 当前cell的下一行
-	nextrow := cells[row][col].row + cells[row][col].rowspan - cells[0][0].row
-	for k := col; k < table.cols; k++ {
-		cell := cells[nextrow][col]
-		_, y, _, _ := table.getHLinePosition(sx, sy, col, nextrow)
-
-		// 空格Cell -> 寻找非空格Cell
-		if cell.rowspan <= 0 {
-			i, j := -cell.rowspan-cells[0][0].row, -cell.colspan-cells[0][0].col
-			wn, _ := cells[i][j].element.TryGenerateAtomicCell(pageEndY - y)
-			if wn > 0 {
-				canwrite = true
-				return canwrite
-			}
-		}
-
-		// 非空格Cell
-		if cell.rowspan >= 1 {
-			wn, _ := cell.element.TryGenerateAtomicCell(pageEndY - y)
-			if wn > 0 {
-				canwrite = true
-				return canwrite
-			}
-		}
+	if table.label != "" {
+		table.labelHeight = table.lineHeight
 	}
-
-	return canwrite
 }
 
-// 对当前的Page进行画线
-func (table *Table) drawPageLines(sx, sy float64) {
-	var (
-		rows, cols          = table.rows, table.cols
-		_, pageEndY         = table.pdf.GetPageEndXY()
-		x, y, x1, y1, _, y2 float64
-	)
+// 在 (x, y) 写入表头, 主体在 pageEndY 之前可以写入的行, 以及表尾. 返回表尾的底部, 没有任何可以写入的行时返回 false
+func (table *Table) writeFragment(x, y, pageEndY float64, force bool) (float64, bool) {
+	table.x = x + table.margin.Left
+	table.cachedCol = nil
 
-	// 计算当前页面最大的rows
-	_, y1 = table.pdf.GetPageStartXY()
-	_, y2 = table.pdf.GetPageEndXY()
-	if rows > int((y2-y1)/table.lineHeight)+1 {
-		rows = int((y2-y1)/table.lineHeight) + 1
+	layout := table.layoutPage(y+table.labelHeight+table.headerHeight, pageEndY, force)
+	if layout.end == layout.start && table.start < table.end {
+		return y, false
 	}
 
-	table.pdf.LineType("straight", 0.1)
-
-	// 两条水平线
-	x, y, _, _ = table.getHLinePosition(sx, sy, 0, 0)
-	table.pdf.LineH(x, y, x+table.width)
-	table.pdf.LineH(x, pageEndY, x+table.width)
-
-	for row := 0; row < rows; row++ {
-		for col := 0; col < cols; col++ {
-			cell := table.cells[row][col]
-
-			if cell.element == nil {
-				continue
-			}
-
-			// 坐标化
-			x, y, x1, y1 = table.getHLinePosition(sx, sy, col, row)
-			x, y, _, y2 = table.getVLinePosition(sx, sy, col, row)
-
-			// cell没有跨页
-			if y1 < pageEndY && y2 < pageEndY {
-				// cell的下一个cell跨页, 需要判断下一个cell是否写入, 当写入了, 需要底线, 否则, 不需要底线
-				i, j := cell.row+cell.rowspan-table.cells[0][0].row, cell.col-table.cells[0][0].col
-				_, y3, _, y4 := table.getVLinePosition(sx, sy, j, i)
-				if y3 < pageEndY && y4 >= pageEndY {
-					if !table.checkNextCellWrited(row, col) {
-						y2 = pageEndY
-						table.pdf.LineV(x1, y1, y2)
-						continue
-					}
-				}
+	table.writeLabel(table.x, y)
+	table.pdf.SetXY(x, y+table.labelHeight)
+	table.writeRepeatRows(table.header)
+	table.writePage(layout)
 
-				table.pdf.LineV(x1, y1, y2)
-				table.pdf.LineH(x, y2, x1)
-			}
-
-			// cell跨页, 需要先判断是否需要竖线
-			if y1 < pageEndY && y2 >= pageEndY {
-				if table.checkNeedVline(row, col) {
-					table.pdf.LineV(x1, y1, pageEndY)
-				}
-
-				table.pdf.LineH(x, pageEndY, x1)
-			}
-		}
+	bottom := layout.bottom
+	if table.footer != nil {
+		table.pdf.SetXY(x, bottom)
+		table.writeRepeatRows(table.footer)
+		bottom += table.footerHeight
 	}
-
-	// 两条垂直线
-	x, y, _, _ = table.getHLinePosition(sx, sy, 0, 0)
-	table.pdf.LineV(x, y, pageEndY)
-	table.pdf.LineV(x+table.width, y, pageEndY)
+	return bottom, true
 }
 
-// 最后一页画线(基本参考了drawPageLines)
-func (table *Table) drawLastPageLines(sx, sy float64) {
+// 分离表头和表尾, 表头和表尾不参与分页
+func (table *Table) splitRepeatRows() {
 	var (
-		rows, cols          = table.rows, table.cols
-		pageEndY            = table.getLastPageHeight()
-		x, y, x1, y1, _, y2 float64
+		header = table.headerRows
+		footer = table.rows - table.footerRows
 	)
 
-	table.pdf.LineType("straight", 0.1)
-
-	x, y, _, _ = table.getHLinePosition(sx, sy, 0, 0)
-	pageEndY = y + pageEndY
-
-	table.pdf.LineH(x, y, x+table.width)
-	table.pdf.LineH(x, pageEndY, x+table.width)
-
-	for row := 0; row < rows; row++ {
-		for col := 0; col < cols; col++ {
-			cell := table.cells[row][col]
-
-			if cell.element == nil {
+	// 跨行的单元格不能跨越表头和表尾的边界
+	for i := 0; i < table.rows; i++ {
+		for j := 0; j < table.cols; j++ {
+			cell := table.cells[i][j]
+			if cell == nil || cell.rowspan < 1 {
 				continue
 			}
-
-			x, y, x1, y1 = table.getHLinePosition(sx, sy, col, row)
-			x, y, _, y2 = table.getVLinePosition(sx, sy, col, row)
-
-			if y1 < pageEndY && y2 < pageEndY {
-				table.pdf.LineV(x1, y1, y2)
-				table.pdf.LineH(x, y2, x1)
-			}
-
-			if y1 < pageEndY && y2 >= pageEndY {
-				table.pdf.LineV(x1, y1, pageEndY)
-				table.pdf.LineH(x, pageEndY, x1)
+			end := cell.row + cell.rowspan
+			if cell.row < header && end > header || cell.row < footer && end > footer {
+				panic("the rowspan cell crosses header or footer rows")
 			}
 		}
 	}
 
-	x, y, _, _ = table.getHLinePosition(sx, sy, 0, 0)
-	table.pdf.LineV(x, y, pageEndY)
-	table.pdf.LineV(x+table.width, y, pageEndY)
-}
-
-func (table *Table) checkNextCellWrited(row, col int) bool {
-	var (
-		cells      = table.cells
-		cellwrited bool
-	)
-
-	if cells[row][col].rowspan <= 0 {
-		return cellwrited
+	if table.headerRows > 0 {
+		table.header = table.cells[:header]
+	}
+	if table.footerRows > 0 {
+		table.footer = table.cells[footer:]
+		table.footerHeight = table.repeatTable(table.footer).getRowsHeight()
 	}
 
-	// row,col所定位的cell必须是非空白cell, 需要定位到下一个非空白cell
-	nextrow := (cells[row][col].row - cells[0][0].row) + cells[row][col].rowspan
-
-	// 如果nexrow行, 从col开始, 一直到col+colspan, 有内容写入, 则说明下一个单元格有内容写入,
-	// 否则, 没有内容写入
-	for k := col; k < col+cells[row][col].colspan; k++ {
-		cell := cells[nextrow][col]
-
-		// 空白cell
-		if cell.rowspan <= 0 {
-			// rowspan = 1 和 rowsapn != 1 需要区别对待, 原因是rowsapn=1, 即使写入了, cellwrited还是可能为0
-			i, j := -cell.rowspan-cells[0][0].row, -cell.colspan-cells[0][0].col
-			if cells[i][j].rowspan == 1 {
-				height := cells[i][j].element.GetHeight()
-				lastheight := cells[i][j].element.GetLastHeight()
-				if math.Abs(lastheight-height) > 0.1 {
-					return true
-				}
-			}
+	table.start, table.end = header, footer
+}
 
-			if cells[i][j].rowspan > 1 {
-				if cells[i][j].cellwrited >= cell.row-cells[i][j].row+1 {
-					return true
+// 使用表头(表尾)的副本构建新的 table, 用于写入. 行号从 0 开始
+func (table *Table) repeatTable(rows [][]*TableCell) *Table {
+	t := &Table{
+		pdf:        table.pdf,
+		rows:       len(rows),
+		cols:       table.cols,
+		width:      table.width,
+		colwidths:  table.colwidths,
+		lineHeight: table.lineHeight,
+		margin:     core.Scope{Left: table.margin.Left, Right: table.margin.Right},
+		cells:      make([][]*TableCell, len(rows)),
+		repeat:     true,
+		end:        len(rows),
+
+		outerBorder: table.outerBorder,
+		innerBorder: table.innerBorder,
+	}
+
+	offset := rows[0][0].row
+	for i := range rows {
+		t.cells[i] = make([]*TableCell, table.cols)
+		for j, cell := range rows[i] {
+			c := *cell
+			c.table = t
+			c.row -= offset
+			if c.rowspan < 1 {
+				c.rowspan += offset
+			}
+			if cell.element != nil {
+				e, ok := cell.element.(CopyableCell)
+				if !ok {
+					panic("the element of header and footer cells must be CopyableCell")
 				}
+				c.element = e.CopyCell()
 			}
-		}
-
-		// 非空白cell
-		if cell.rowspan >= 1 {
-			height := cell.element.GetHeight()
-			lastheight := cell.element.GetLastHeight()
-			if math.Abs(lastheight-height) > 0.1 {
-				return true
-			}
+			t.cells[i][j] = &c
 		}
 	}
 
-	return cellwrited
+	return t
 }
 
-func (table *Table) checkNeedVline(row, col int) bool {
-	var (
-		negwrited bool
-		curwrited bool
-		cells     = table.cells
-		origin    = cells[0][0]
-	)
-
-	if cells[row][col].rowspan <= 0 {
-		return negwrited || curwrited
+// 在当前的位置写入表头(表尾)
+func (table *Table) writeRepeatRows(rows [][]*TableCell) {
+	if rows == nil {
+		return
 	}
 
-	// row,col 所确定的cell必须是非空白cell. 只有当前非空白cell没有写入 && 邻居cell没有写入 => false, 否则, 返回 true
-
-	// 当前的cell
-	if cells[row][col].rowspan >= 1 {
-		height := cells[row][col].element.GetHeight()
-		lastheight := cells[row][col].element.GetLastHeight()
-		if math.Abs(lastheight-height) > 0.1 || cells[row][col].cellwrited == cells[row][col].rowspan {
-			curwrited = true
-		}
-	}
+	x, _ := table.pdf.GetXY()
+	table.repeatTable(rows).GenerateAtomicCell()
+	_, y := table.pdf.GetXY()
+	table.pdf.SetXY(x, y)
+}
 
-	// 邻居cell
-	nextcol := cells[row][col].col + cells[row][col].colspan - cells[0][0].col
-	if nextcol == table.cols {
-		return true
-	}
+// 所有行的高度(不分页)
+func (table *Table) getRowsHeight() float64 {
+	return table.layoutPage(0, math.MaxFloat64, true).bottom
+}
 
-	if cells[row][nextcol].rowspan <= 0 {
-		row, nextcol = -cells[row][nextcol].rowspan-origin.row, -cells[row][nextcol].colspan-origin.col
-	}
-	if cells[row][nextcol].rowspan >= 1 || cells[row][nextcol].cellwrited == cells[row][nextcol].rowspan {
-		height := cells[row][nextcol].element.GetHeight()
-		lastheight := cells[row][nextcol].element.GetLastHeight()
-		if math.Abs(lastheight-height) > 0.1 {
-			return true
-		}
+// 页面的结束位置, 需要预留表尾的高度
+func (table *Table) getPageEndY() float64 {
+	if table.repeat {
+		return math.MaxFloat64
 	}
 
-	return negwrited || curwrited
+	_, y := table.pdf.GetPageEndXY()
+	return y - table.footerHeight
 }
 
-// 重新计算 tablecell 的高度(精确)
-func (table *Table) resetCellHeight() {
-	table.checkTableConstraint()
-
-	// 计算当前页面最大的rows
-	_, y1 := table.pdf.GetPageStartXY()
-	_, y2 := table.pdf.GetPageEndXY()
-	rows := table.rows
-	if rows > int((y2-y1)/table.lineHeight)+1 {
-		rows = int((y2-y1)/table.lineHeight) + 1
-	}
-	cells := table.cells
-
-	// 对于cells的元素重新赋值height和minheight
-	for i := 0; i < rows; i++ {
-		for j := 0; j < table.cols; j++ {
-			cells[i][j].minheight = table.lineHeight
-			cells[i][j].height = table.lineHeight
+// 从 table.start 开始计算当前页面可以写入的行. 行高是当前行单元格剩余内容的最大高度,
+// 跨越多行的单元格不足的高度由其最后一行补齐. 跨页的行至少有一个单元格可以写入部分内容时拆分,
+// 否则在该行之前换页. force 表示至少写入一行.
+func (table *Table) layoutPage(y, pageEndY float64, force bool) tableLayout {
+	var (
+		layout  = tableLayout{start: table.start, end: table.start, bottom: y, continued: table.split}
+		pending []*TableCell // 跨越多行, 还没有结束的单元格
+	)
 
-			if cells[i][j].element != nil {
-				cells[i][j].height = cells[i][j].element.GetHeight()
-				if cells[i][j].rowspan == 1 {
-					cells[i][j].minheight = cells[i][j].height
-				}
+	for row := table.start; row < table.end; row++ {
+		var height float64
+		if row != table.start || !table.split {
+			height = table.lineHeight
+			if row < len(table.rowheights) {
+				height = math.Max(height, table.rowheights[row])
 			}
 		}
-	}
 
-	// 第一遍计算rowspan是1的高度
-	for i := 0; i < rows; i++ {
-		var max float64 // 当前行的最大高度
-		for j := 0; j < table.cols; j++ {
-			if max < cells[i][j].minheight {
-				max = cells[i][j].minheight
+		cells := table.rowCells(row)
+		for _, cell := range cells {
+			if table.lastRow(cell) == row {
+				height = math.Max(height, table.cellHeight(cell))
+			} else {
+				pending = append(pending, cell)
 			}
 		}
 
-		for j := 0; j < table.cols; j++ {
-			if cells[i][j] != nil {
-				cells[i][j].minheight = max // todo: 当前行(包括空白)的自身高度
+		var rest []*TableCell
+		for _, cell := range pending {
+			if table.lastRow(cell) > row {
+				rest = append(rest, cell)
+				continue
+			}
 
-				if cells[i][j].rowspan == 1 || cells[i][j].rowspan < 0 {
-					cells[i][j].height = max
-				}
+			first := cell.row
+			if first < table.start {
+				first = table.start
 			}
+			height = math.Max(height, table.cellHeight(cell)-(y-layout.tops[first-table.start]))
 		}
-	}
+		pending = rest
 
-	// 第二遍计算rowsapn非1的行高度
-	for i := 0; i < rows; i++ {
-		for j := 0; j < table.cols; j++ {
-			if cells[i][j] != nil && cells[i][j].rowspan > 1 {
+		if y+height <= pageEndY+0.01 {
+			layout.tops = append(layout.tops, y)
+			layout.heights = append(layout.heights, height)
+			layout.end, y = row+1, y+height
+			layout.bottom = y
+			continue
+		}
 
-				var totalHeight float64
-				for k := 0; k < cells[i][j].rowspan; k++ {
-					totalHeight += cells[i+k][j].minheight // todo: 计算所有行的高度
-				}
+		// 页面的顶部无法满足分页控制的时候, 在页面的底部拆分
+		top := force && row == table.start
+		split := 0.0
+		if !table.keepTogether[row] {
+			split = table.splitHeight(cells, pageEndY-y, true)
+		}
+		if split == 0 && top {
+			split = table.splitHeight(cells, pageEndY-y, false)
+		}
 
-				if totalHeight < cells[i][j].height {
-					h := cells[i][j].height - totalHeight
-
-					row := (cells[i][j].row - cells[0][0].row) + cells[i][j].rowspan - 1 // 最后一行
-					for col := 0; col < table.cols; col++ {
-						// 更新minheight
-						cells[row][col].minheight += h
-
-						// 更新height, 当rowspan=1
-						if cells[row][col].rowspan == 1 {
-							cells[row][col].height += h
-						}
-
-						// 更新height, 当rowspan<0, 空格,需要更新非当前的实体(前面的)
-						if cells[row][col].rowspan <= 0 {
-							cells[row][col].height += h
-
-							orow := -cells[row][col].rowspan - cells[0][0].row
-							ocol := -cells[row][col].colspan - cells[0][0].col
-							if orow == i && ocol < j || orow < i {
-								cells[orow][ocol].height += h
-							}
-						}
-					}
-				} else {
-					cells[i][j].height = totalHeight
-				}
-			}
+		switch {
+		case split > 0:
+			layout.tops = append(layout.tops, y)
+			layout.heights = append(layout.heights, split)
+			layout.end, layout.bottom, layout.split = row+1, y+split, true
+		case top:
+			// 强制写入的单元格(嵌套的表格等)可能只写入一部分, 剩余的内容在下一页继续
+			layout.tops = append(layout.tops, y)
+			layout.heights = append(layout.heights, height)
+			layout.end, layout.bottom, layout.split = row+1, y+height, true
 		}
+		return table.keepRows(layout, force)
 	}
 
-	table.cells = cells
+	return layout
 }
 
-func (table *Table) getMaxWriteLineNo() int {
-	var (
-		cells    = table.cells
-		writenum int
-	)
-
-	_, y1 := table.pdf.GetPageStartXY()
-	_, y2 := table.pdf.GetPageEndXY()
-	rows := table.rows
-	if rows > int((y2-y1)/table.lineHeight)+1 {
-		rows = int((y2-y1)/table.lineHeight) + 1
-	}
-
-	// todo: 计算当前的已经当前行全部写入的最大的行数.
-	for i := 0; i < rows; i++ {
-		for j := 0; j < table.cols; j++ {
-			cell := cells[i][j]
-			if cell.rowspan < 1 {
-				row, col := -cell.rowspan-cells[0][0].row, -cell.colspan-cells[0][0].col
-				if cells[row][col].row+cells[row][col].cellwrited <= cell.row {
-					return writenum
-				}
-			}
-
-			if cell.rowspan == 1 {
-				if cell.element.GetHeight() != 0 {
-					return writenum
-				}
+// 写入当前页面的单元格, 然后画线
+func (table *Table) writePage(layout tableLayout) {
+	var fragments []tableFragment
+	for row := layout.start; row < layout.end; row++ {
+		for _, cell := range table.rowCells(row) {
+			last := table.lastRow(cell)
+			if last > layout.end-1 {
+				last = layout.end - 1
 			}
 
-			if cell.rowspan > 1 {
-				if cell.cellwrited < 1 {
-					return writenum
-				}
+			top := layout.tops[row-layout.start]
+			bottom := layout.tops[last-layout.start] + layout.heights[last-layout.start]
+			if cell.element != nil {
+				table.pdf.SetXY(table.getColX(cell.col), top)
+				cell.element.GenerateAtomicCell(bottom - top)
 			}
+			fragments = append(fragments, tableFragment{cell: cell, top: top, bottom: bottom})
 		}
-
-		writenum = i + 1
 	}
 
-	return writenum
-}
+	table.drawLines(layout, fragments)
 
-// 重置cells
-func (table *Table) resetTableCells() {
-	var (
-		cells  = table.cells
-		origin = cells[0][0]
-	)
-
-	writenum := table.getMaxWriteLineNo()
-	// TODO: fix writenum is out of table.cells
-	if writenum >= len(table.cells) {
-		table.cells = table.cells[writenum:]
-		return
-	}
-
-	// cell重置,需要修正空格Cell
-	row := writenum
-	for col := 0; col < table.cols; {
-		cell := table.cells[row][col]
-
-		if cell.rowspan < 1 {
-			var ox, oy int
-
-			orow, ocol := -cell.rowspan-origin.row, -cell.colspan-origin.col
-			for x := row; x < cells[orow][ocol].row+cells[orow][ocol].rowspan-origin.row; x++ {
-				for y := col; y < col+cells[orow][ocol].colspan; y++ {
-					if x == row && y == col {
-						ox, oy = cells[x][y].row, cells[x][y].col
-
-						cells[x][y].element = cells[orow][ocol].element
-						cells[x][y].rowspan = cells[orow][ocol].rowspan - (ox - cells[orow][ocol].row)
-						cells[x][y].colspan = cells[orow][ocol].colspan
-						cells[x][y].cellwrited = 0
-
-						continue
-					}
-
-					cells[x][y].rowspan = -ox
-					cells[x][y].colspan = -oy
-				}
+	// 下一页开始的行. 拆分的行全部写完的时候, 从下一行开始
+	table.start, table.split = layout.end, false
+	if layout.split {
+		for _, fragment := range fragments {
+			if table.lastRow(fragment.cell) == layout.end-1 && table.cellHeight(fragment.cell) > 0 {
+				table.start, table.split = layout.end-1, true
+				break
 			}
-
-			col += cells[orow][ocol].colspan
-			continue
 		}
+	}
+}
 
+// 从 row 行开始的单元格. 当前页面的第一行还包括从上一页延续的单元格
+func (table *Table) rowCells(row int) []*TableCell {
+	var cells []*TableCell
+	for col, cell := range table.cells[row] {
 		if cell.rowspan >= 1 {
-			col += cell.colspan
-			cell.cellwrited = 0
+			cells = append(cells, cell)
 			continue
 		}
-	}
-
-	table.cells = table.cells[writenum:]
-}
-
-func (table *Table) cachedPoints(sx, sy float64) {
-	// 只计算当前页面最大的rows
-	_, y1 := table.pdf.GetPageStartXY()
-	_, y2 := table.pdf.GetPageEndXY()
-	rows := table.rows
-	if rows > int((y2-y1)/table.lineHeight)+1 {
-		rows = int((y2-y1)/table.lineHeight) + 1
-	}
-
-	var (
-		x, y = sx+table.margin.Left, sy+table.margin.Top
-	)
-
-	// 只会缓存一次
-	if table.cachedCol == nil {
-		table.cachedCol = make([]float64, table.cols)
 
-		for col := 0; col < table.cols; col++ {
-			table.cachedCol[col] = x
-			x += table.colwidths[col] * table.width
+		origin := table.cells[-cell.rowspan][-cell.colspan]
+		if row == table.start && origin.row < row && origin.col == col {
+			cells = append(cells, origin)
 		}
 	}
-	table.cachedRow = make([]float64, rows)
-
-	for row := 0; row < rows; row++ {
-		table.cachedRow[row] = y
-		y += table.cells[row][0].minheight
-	}
+	return cells
 }
 
-// 垂直线, table单元格的垂直线
-func (table *Table) getVLinePosition(sx, sy float64, col, row int) (x1, y1 float64, x2, y2 float64) {
-	var (
-		x, y float64
-		cell = table.cells[row][col]
-	)
-
-	x = table.cachedCol[col]
-	y = table.cachedRow[row]
-
-	return x, y, x, y + cell.height
+// 单元格的最后一行
+func (table *Table) lastRow(cell *TableCell) int {
+	return cell.row + cell.rowspan - 1
 }
 
-// 水平线, table单元格的水平线
-func (table *Table) getHLinePosition(sx, sy float64, col, row int) (x1, y1 float64, x2, y2 float64) {
-	var (
-		x, y float64
-	)
+// 单元格剩余内容的高度
+func (table *Table) cellHeight(cell *TableCell) float64 {
+	if cell.element == nil {
+		return 0
+	}
+	return cell.element.GetHeight()
+}
 
-	x = table.cachedCol[col]
-	y = table.cachedRow[row]
+// 列的开始位置, col == cols 是表格的右侧
+func (table *Table) getColX(col int) float64 {
+	if table.cachedCol == nil {
+		table.cachedCol = make([]float64, table.cols+1)
 
-	cell := table.cells[row][col]
-	if cell.colspan > 1 {
-		if cell.col+cell.colspan == table.cols {
-			x1 = table.cachedCol[0] + table.width
-		} else {
-			x1 = table.cachedCol[cell.col+cell.colspan]
+		x := table.x
+		for i := 0; i < table.cols; i++ {
+			table.cachedCol[i] = x
+			x += table.colwidths[i] * table.width
 		}
-	} else {
-		x1 = x + table.colwidths[col]*table.width
+		table.cachedCol[table.cols] = x
 	}
 
-	return x, y, x1, y
-}
-
-// 获取表的垂直高度
-func (table *Table) getLastPageHeight() float64 {
-	var count float64
-	for i := 0; i < table.rows; i++ {
-		count += table.cells[i][0].minheight
-	}
-	return count
+	return table.cachedCol[col]
 }
 
 // 校验table是否合法(只做一次)
@@ -2,7 +2,9 @@ package gopdf
 
 import (
 	"fmt"
+	"math"
 	"math/rand"
+	"strconv"
 	"strings"
 	"time"
 	"testing"
@@ -303,3 +305,969 @@ func TestManyTableReportWithData(t *testing.T) {
 	end := time.Now().Unix()
 	fmt.Println("i", 1, end-start)
 }
+
+func TableReport(fn func(report *core.Report)) *core.Report {
+	r := core.CreateReport()
+	font := core.FontMap{
+		FontName: TABLE_MD,
+		FileName: "example//ttf/mplus-1p-bold.ttf",
+	}
+	r.SetFonts([]*core.FontMap{&font})
+	r.SetPage("A4", "P")
+
+	r.RegisterExecutor(core.Executor(fn), core.Detail)
+	return r
+}
+
+// 按照页面分组的文字: 内容 -> y
+func tablePageTexts(cells []string) []map[string][]float64 {
+	pages := []map[string][]float64{{}}
+	for _, cell := range cells {
+		if cell == "NP" {
+			pages = append(pages, map[string][]float64{})
+			continue
+		}
+		if strings.HasPrefix(cell, "CL|") {
+			tokens := strings.SplitN(cell, "|", 4)
+			y, _ := strconv.ParseFloat(tokens[2], 64)
+			pages[len(pages)-1][tokens[3]] = append(pages[len(pages)-1][tokens[3]], y)
+		}
+	}
+	return pages
+}
+
+func TestTableHeaderFooter(t *testing.T) {
+	var (
+		font     = core.Font{Family: TABLE_MD, Size: 10}
+		rows     = 80
+		pageEndY float64
+	)
+
+	r := TableReport(func(report *core.Report) {
+		_, pageEndY = report.GetPageEndXY()
+		table := NewTable(3, rows+3, 400, 18, report)
+		table.SetHeaderRows(2)
+		table.SetFooterRows(1)
+
+		text := func(row, col int, content string) *TextCell {
+			return NewTextCell(table.GetColWidth(row, col), 18, 1, report).SetFont(font).SetContent(content)
+		}
+
+		table.NewCellByRange(2, 1).SetElement(text(0, 0, "Name").SetBackColor("200,200,200"))
+		table.NewCellByRange(1, 2).SetElement(text(0, 2, "Value"))
+		table.NewCell().SetElement(text(1, 0, "First"))
+		table.NewCell().SetElement(text(1, 1, "Last"))
+		for i := 0; i < rows; i++ {
+			for j := 0; j < 3; j++ {
+				table.NewCell().SetElement(text(i+2, j, fmt.Sprintf("row-%v-%v", i, j)))
+			}
+		}
+		table.NewCellByRange(2, 1).SetElement(text(rows+2, 0, "Total"))
+		table.NewCell().SetElement(text(rows+2, 2, "Sum"))
+
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	var (
+		cells  = *r.GetAtomicCells()
+		pages  = tablePageTexts(cells)
+		writed = map[string]int{}
+	)
+	if len(pages) < 2 {
+		t.Fatalf("expect more than one page, got %v", len(pages))
+	}
+
+	for i, page := range pages {
+		for _, head := range []string{"Name", "Value", "First", "Last", "Total", "Sum"} {
+			if len(page[head]) != 1 {
+				t.Fatalf("page %v: expect %q once, got %v", i, head, len(page[head]))
+			}
+		}
+
+		header, footer := page["First"][0], page["Total"][0]
+		for text, ys := range page {
+			if !strings.HasPrefix(text, "row-") {
+				continue
+			}
+			writed[text] += len(ys)
+			if ys[0] < header || ys[0] > footer {
+				t.Errorf("page %v: %v is not between header and footer", i, text)
+			}
+		}
+
+		if footer+18 > pageEndY+0.01 {
+			t.Errorf("page %v: footer is out of page: %v", i, footer)
+		}
+	}
+
+	if len(writed) != rows*3 {
+		t.Errorf("expect %v data cells, got %v", rows*3, len(writed))
+	}
+	for text, count := range writed {
+		if count != 1 {
+			t.Errorf("%v is written %v times", text, count)
+		}
+	}
+
+	// 表头的背景颜色在每一页重复
+	var backgrounds int
+	for _, cell := range cells {
+		if strings.HasPrefix(cell, "BC|") && strings.HasSuffix(cell, "|200|200|200|0000") {
+			backgrounds++
+		}
+	}
+	if backgrounds != len(pages) {
+		t.Errorf("expect %v header backgrounds, got %v", len(pages), backgrounds)
+	}
+}
+
+func TestTableHeaderRowspan(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for rowspan crossing the header")
+		}
+	}()
+
+	TableReport(func(report *core.Report) {
+		font := core.Font{Family: TABLE_MD, Size: 10}
+		table := NewTable(2, 3, 400, 18, report)
+		table.SetHeaderRows(1)
+		table.NewCellByRange(1, 2).SetElement(NewTextCell(200, 18, 1, report).SetFont(font).SetContent("span"))
+		for i := 0; i < 4; i++ {
+			table.NewCell().SetElement(NewTextCell(200, 18, 1, report).SetFont(font).SetContent("cell"))
+		}
+		table.GenerateAtomicCell()
+	}).GetBytesPdf()
+}
+
+func TestTableColumnWidths(t *testing.T) {
+	TableReport(func(report *core.Report) {
+		table := NewTable(4, 1, 400, 18, report)
+		table.SetColumnWidths("100", "25%", "*", "2*")
+		for j := 0; j < 4; j++ {
+			table.NewCell()
+		}
+
+		expect := []float64{100, 100, 200.0 / 3, 400.0 / 3}
+		for j, width := range expect {
+			if w := table.GetColWidth(0, j); math.Abs(w-width) > 0.01 {
+				t.Errorf("column %v: expect %v, got %v", j, width, w)
+			}
+		}
+
+		// 只有绝对宽度, table 的宽度是列宽之和
+		table = NewTable(2, 1, 400, 18, report)
+		table.SetColumnWidths("100", "50")
+		if table.width != 150 {
+			t.Errorf("expect table width 150, got %v", table.width)
+		}
+
+		// 重复设置的时候, 百分比仍然基于设置的宽度
+		table = NewTable(2, 1, 400, 18, report)
+		table.SetColumnWidths("25%", "25%")
+		table.SetColumnWidths("25%", "25%")
+		table.SetColumnWidths("*", "*")
+		if table.width != 400 {
+			t.Errorf("expect table width 400, got %v", table.width)
+		}
+		table.SetColumnWidths("25%", "25%")
+		if w := table.colwidths[0] * table.width; table.width != 200 || w != 100 {
+			t.Errorf("expect table width 200 and column 100, got %v %v", table.width, w)
+		}
+	}).GetBytesPdf()
+}
+
+func TestTableColumnAuto(t *testing.T) {
+	var (
+		font   = core.Font{Family: TABLE_MD, Size: 10}
+		long   = strings.Repeat("a long description that wraps ", 6)
+		widths []float64
+		cell   *TextCell
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table := NewTable(3, 3, 400, 18, report)
+		table.SetColumnWidths("auto", "auto", "auto")
+
+		contents := [][]string{{"ID", "Description", "Amount"}, {"1", long, "12.50"}, {"22", "short", "1024.00"}}
+		for i := range contents {
+			for j, content := range contents[i] {
+				c := table.NewCell()
+				e := NewTextCell(table.GetColWidth(i, j), 18, 1, report).SetFont(font).SetContent(content)
+				c.SetElement(e)
+				if i == 1 && j == 1 {
+					cell = e
+				}
+			}
+		}
+
+		table.GenerateAtomicCell()
+		for j := 0; j < 3; j++ {
+			widths = append(widths, table.colwidths[j]*table.width)
+		}
+	})
+	r.GetBytesPdf()
+
+	// 不能换行的列使用最大宽度, 其余的宽度给可以换行的列
+	id := math.Max(r.MeasureTextWidthWithFont(font, "ID"), r.MeasureTextWidthWithFont(font, "22"))
+	amount := r.MeasureTextWidthWithFont(font, "1024.00")
+	if math.Abs(widths[0]-id) > 0.01 || math.Abs(widths[2]-amount) > 0.01 {
+		t.Errorf("expect widths %v %v, got %v", id, amount, widths)
+	}
+	if math.Abs(widths[0]+widths[1]+widths[2]-400) > 0.01 {
+		t.Errorf("expect total width 400, got %v", widths)
+	}
+
+	// 单元格的元素按照新的宽度写入
+	if cell.width != widths[1] {
+		t.Errorf("expect element width %v, got %v", widths[1], cell.width)
+	}
+	var lines int
+	for _, c := range *r.GetAtomicCells() {
+		if strings.HasPrefix(c, "CL|") && strings.Contains(c, "wraps") {
+			lines++
+			content := strings.SplitN(c, "|", 4)[3]
+			if w := r.MeasureTextWidthWithFont(font, content); w > widths[1]+0.01 {
+				t.Errorf("line %q is wider than column: %v", content, w)
+			}
+		}
+	}
+	if lines < 2 {
+		t.Errorf("expect wrapped description, got %v lines", lines)
+	}
+}
+
+func TestTableColumnInvalid(t *testing.T) {
+	for _, widths := range [][]string{{"abc", "1"}, {"-1", "1"}, {"300", "200"}, {"1"}} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic for %v", widths)
+				}
+			}()
+			NewTable(2, 1, 400, 18, core.CreateReport()).SetColumnWidths(widths...)
+		}()
+	}
+}
+
+// 一页当中的原子操作: 文字, 背景颜色, 水平线, 垂直线
+type tablePageCells struct {
+	texts       []string
+	backgrounds [][4]float64 // x, y, w, h
+	colors      []string
+	hlines      [][3]float64 // x1, y, x2
+	vlines      [][3]float64 // x, y1, y2
+}
+
+func tablePages(cells []string) []*tablePageCells {
+	var (
+		pages = []*tablePageCells{{}}
+		atof  = func(s string) float64 {
+			v, _ := strconv.ParseFloat(s, 64)
+			return v
+		}
+	)
+	for _, cell := range cells {
+		page := pages[len(pages)-1]
+		tokens := strings.Split(cell, "|")
+		switch tokens[0] {
+		case "NP":
+			pages = append(pages, &tablePageCells{})
+		case "CL":
+			page.texts = append(page.texts, strings.SplitN(cell, "|", 4)[3])
+		case "BC":
+			page.backgrounds = append(page.backgrounds, [4]float64{atof(tokens[1]), atof(tokens[2]), atof(tokens[3]), atof(tokens[4])})
+			page.colors = append(page.colors, strings.Join(tokens[5:8], ","))
+		case "LH":
+			page.hlines = append(page.hlines, [3]float64{atof(tokens[1]), atof(tokens[2]), atof(tokens[3])})
+		case "LV":
+			page.vlines = append(page.vlines, [3]float64{atof(tokens[1]), atof(tokens[2]), atof(tokens[3])})
+		}
+	}
+	return pages
+}
+
+// 位置 at 的线条是否覆盖 [from, to]
+func linesCover(lines [][3]float64, at, from, to float64) bool {
+	var segments [][2]float64
+	for _, line := range lines {
+		if math.Abs(line[1]-at) < 0.1 {
+			segments = append(segments, [2]float64{line[0], line[2]})
+		}
+	}
+
+	for changed := true; changed; {
+		changed = false
+		for _, segment := range segments {
+			if segment[0] <= from+0.1 && segment[1] > from+0.1 {
+				from, changed = segment[1], true
+			}
+		}
+	}
+	return from >= to-0.1
+}
+
+// 垂直线转换为 (y, x1, x2) 的格式, 方便使用 linesCover
+func transposeLines(lines [][3]float64) (result [][3]float64) {
+	for _, line := range lines {
+		result = append(result, [3]float64{line[1], line[0], line[2]})
+	}
+	return result
+}
+
+// 检查每一页的表格: 四条外边框完整, 背景颜色在页面之内. 返回所有的单词
+func checkTablePages(t *testing.T, pages []*tablePageCells, x, width, startY, endY float64) map[string]int {
+	words := map[string]int{}
+	for i, page := range pages {
+		for _, text := range page.texts {
+			for _, word := range strings.Fields(text) {
+				words[word]++
+			}
+		}
+
+		if len(page.hlines) == 0 {
+			continue
+		}
+		top, bottom := math.MaxFloat64, 0.0
+		for _, line := range page.hlines {
+			top, bottom = math.Min(top, line[1]), math.Max(bottom, line[1])
+		}
+		if top < startY-0.1 || bottom > endY+0.1 {
+			t.Errorf("page %v: table is out of page: %v %v", i, top, bottom)
+		}
+
+		if !linesCover(page.hlines, top, x, x+width) || !linesCover(page.hlines, bottom, x, x+width) {
+			t.Errorf("page %v: top or bottom border is incomplete", i)
+		}
+		vlines := transposeLines(page.vlines)
+		if !linesCover(vlines, x+0.05, top-0.05, bottom-0.05) || !linesCover(vlines, x+width+0.05, top-0.05, bottom-0.05) {
+			t.Errorf("page %v: left or right border is incomplete", i)
+		}
+
+		for k, bg := range page.backgrounds {
+			if bg[1] < top-0.1 || bg[1]+bg[3] > bottom+0.1 {
+				t.Errorf("page %v: background %v is out of table [%v, %v]", i, bg, top, bottom)
+			}
+
+			// 单元格的片段不能重叠
+			for _, other := range page.backgrounds[k+1:] {
+				w := math.Min(bg[0]+bg[2], other[0]+other[2]) - math.Max(bg[0], other[0])
+				h := math.Min(bg[1]+bg[3], other[1]+other[3]) - math.Max(bg[1], other[1])
+				if w > 0.1 && h > 0.1 {
+					t.Errorf("page %v: background %v overlaps %v", i, bg, other)
+				}
+			}
+		}
+	}
+	return words
+}
+
+func TestTableSplitRowspan(t *testing.T) {
+	var (
+		font         = core.Font{Family: TABLE_MD, Size: 10}
+		x            float64
+		startY, endY float64
+		long         []string
+	)
+	for i := 0; i < 30; i++ {
+		long = append(long, fmt.Sprintf("t%02d", i))
+	}
+
+	r := TableReport(func(report *core.Report) {
+		x, startY = report.GetPageStartXY()
+		_, endY = report.GetPageEndXY()
+		report.SetXY(x, endY-60)
+
+		table := NewTable(3, 4, 300, 18, report)
+		text := func(row, col int, content string) *TextCell {
+			return NewTextCell(table.GetColWidth(row, col), 18, 1, report).SetFont(font).SetContent(content)
+		}
+
+		table.NewCellByRange(1, 3).SetElement(text(0, 0, strings.Join(long, " ")).SetBackColor("255,0,0"))
+		for i := 0; i < 3; i++ {
+			table.NewCell().SetElement(text(i, 1, fmt.Sprintf("a%v", i)))
+			table.NewCell().SetElement(text(i, 2, fmt.Sprintf("b%v", i)).SetBackColor("0,0,255"))
+		}
+		for j := 0; j < 3; j++ {
+			table.NewCell().SetElement(text(3, j, fmt.Sprintf("c%v", j)))
+		}
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	pages := tablePages(*r.GetAtomicCells())
+	if len(pages) != 2 {
+		t.Fatalf("expect 2 pages, got %v", len(pages))
+	}
+
+	words := checkTablePages(t, pages, x, 300, startY, endY)
+	for _, word := range append(long, "a0", "a1", "a2", "b0", "b1", "b2", "c0", "c1", "c2") {
+		if words[word] != 1 {
+			t.Errorf("expect %v once, got %v", word, words[word])
+		}
+	}
+
+	// 跨页的单元格在两页都有背景颜色, 填满到页面的底部和从页面的顶部开始
+	var red [][4]float64
+	for i, page := range pages {
+		for k, color := range page.colors {
+			if color == "255,0,0" {
+				red = append(red, page.backgrounds[k])
+				if i == 0 && math.Abs(page.backgrounds[k][1]+page.backgrounds[k][3]-endY) > 0.01 {
+					t.Errorf("first part of background should end at page end: %v", page.backgrounds[k])
+				}
+				if i == 1 && math.Abs(page.backgrounds[k][1]-startY) > 0.01 {
+					t.Errorf("second part of background should start at page start: %v", page.backgrounds[k])
+				}
+			}
+		}
+	}
+	if len(red) != 2 {
+		t.Errorf("expect background on both pages, got %v", red)
+	}
+}
+
+func TestTableSplitLayouts(t *testing.T) {
+	var (
+		font         = core.Font{Family: TABLE_MD, Size: 10}
+		random       = rand.New(rand.NewSource(1))
+		x            float64
+		startY, endY float64
+		rows, cols   = 60, 5
+		fillers      int
+	)
+
+	r := TableReport(func(report *core.Report) {
+		x, startY = report.GetPageStartXY()
+		_, endY = report.GetPageEndXY()
+
+		table := NewTable(cols, rows, 400, 18, report)
+		for table.nextrow != -1 {
+			row, col := table.nextrow, table.nextcol
+			w, h := 1, 1
+			if random.Intn(4) == 0 && col+1 < cols && table.cells[row][col+1] == nil {
+				w = 2
+			}
+			if random.Intn(4) == 0 && row+3 < rows {
+				h = 1 + random.Intn(3)
+			}
+			if !table.checkSpan(row, col, h, w) {
+				w, h = 1, 1
+			}
+
+			n := random.Intn(40)
+			fillers += n
+			cell := table.NewCellByRange(w, h)
+			content := fmt.Sprintf("r%vc%v ", row, col) + strings.Repeat("xx ", n)
+			cell.SetElement(NewTextCell(table.GetColWidth(row, col), 18, 1, report).SetFont(font).
+				SetBackColor(GetRandColor()).SetBorder(core.NewScope(2, 2, 2, 0)).SetContent(content))
+		}
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	pages := tablePages(*r.GetAtomicCells())
+	if len(pages) < 3 {
+		t.Fatalf("expect more than 2 pages, got %v", len(pages))
+	}
+
+	words := checkTablePages(t, pages, x, 400, startY, endY)
+	if words["xx"] != fillers {
+		t.Errorf("expect %v filler words, got %v", fillers, words["xx"])
+	}
+	for word, count := range words {
+		if word != "xx" && count != 1 {
+			t.Errorf("%v is written %v times", word, count)
+		}
+	}
+}
+
+// 原子操作当中的线条, 包括线型, 宽度和颜色. 位置已经去掉了线宽的调整
+type tableLine struct {
+	kind, color  string
+	width        float64
+	horizontal   bool
+	at, from, to float64
+}
+
+func tableLines(cells []string) (lines []tableLine) {
+	var (
+		kind, color = "straight", ""
+		width       float64
+	)
+	for _, cell := range cells {
+		tokens := strings.Split(cell, "|")
+		atof := func(i int) float64 {
+			v, _ := strconv.ParseFloat(tokens[i], 64)
+			return v
+		}
+		switch tokens[0] {
+		case "LT":
+			kind, width = tokens[1], atof(2)
+		case "LC":
+			color = strings.Join(tokens[1:4], ",")
+		case "LH":
+			lines = append(lines, tableLine{kind: kind, color: color, width: width, horizontal: true, at: atof(2) - width/2, from: atof(1), to: atof(3)})
+		case "LV":
+			lines = append(lines, tableLine{kind: kind, color: color, width: width, at: atof(1) - width/2, from: atof(2), to: atof(3)})
+		}
+	}
+	return lines
+}
+
+// 经过 (x, y) 的线条
+func linesAt(lines []tableLine, horizontal bool, x, y float64) (result []tableLine) {
+	for _, line := range lines {
+		if line.horizontal != horizontal {
+			continue
+		}
+		at, pos := y, x
+		if !horizontal {
+			at, pos = x, y
+		}
+		if math.Abs(line.at-at) < line.width+0.01 && line.from-0.01 < pos && pos < line.to+0.01 {
+			result = append(result, line)
+		}
+	}
+	return result
+}
+
+func TestTableBorderStyles(t *testing.T) {
+	var (
+		font = core.Font{Family: TABLE_MD, Size: 10}
+		x, y float64
+	)
+
+	r := TableReport(func(report *core.Report) {
+		x, y = report.GetXY()
+		table := NewTable(2, 2, 200, 20, report)
+		table.SetOuterBorder(Border{Width: 1, Color: "255,0,0"})
+		table.SetInnerBorder(Border{Style: BORDER_NONE})
+
+		cells := make([]*TableCell, 4)
+		for i := range cells {
+			cells[i] = table.NewCell()
+			cells[i].SetElement(NewTextCell(100, 20, 0, report).SetFont(font).SetContent("cell"))
+		}
+		cells[0].SetBorders(Border{}, Border{}, Border{}, Border{Style: BORDER_DASHED, Width: 0.5})
+		cells[3].SetBorder(Border{Style: BORDER_DOUBLE, Width: 1.5, Color: "0,0,255"})
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	lines := tableLines(*r.GetAtomicCells())
+	check := func(name string, found []tableLine, count int, kind, color string, width float64) {
+		if len(found) != count {
+			t.Errorf("%v: expect %v lines, got %v", name, count, found)
+			return
+		}
+		for _, line := range found {
+			if line.kind != kind || line.color != color || math.Abs(line.width-width) > 0.01 {
+				t.Errorf("%v: expect %v %v %v, got %+v", name, kind, color, width, line)
+			}
+		}
+	}
+
+	check("outer left", linesAt(lines, false, x, y+10), 1, "straight", "255,0,0", 1)
+	check("outer top", linesAt(lines, true, x+50, y), 1, "straight", "255,0,0", 1)
+	check("inner none", linesAt(lines, false, x+100, y+10), 0, "", "", 0)
+	check("explicit bottom", linesAt(lines, true, x+50, y+20), 1, "dashed", "0,0,0", 0.5)
+	check("double top", linesAt(lines, true, x+150, y+20), 2, "straight", "0,0,255", 0.5)
+	check("double left", linesAt(lines, false, x+100, y+30), 2, "straight", "0,0,255", 0.5)
+	check("double outer", linesAt(lines, false, x+200, y+30), 2, "straight", "0,0,255", 0.5)
+
+	cells := *r.GetAtomicCells()
+	if cells[len(cells)-1] != "LC|1|1|1" {
+		t.Errorf("expect line color reset, got %v", cells[len(cells)-1])
+	}
+}
+
+func TestTableBorderCollapse(t *testing.T) {
+	var (
+		table  = NewTable(1, 1, 100, 20, core.CreateReport())
+		none   = Border{Style: BORDER_NONE}
+		thin   = Border{Style: BORDER_SOLID, Width: 0.5}
+		thick  = Border{Style: BORDER_DOTTED, Width: 2}
+		dashed = Border{Style: BORDER_DASHED, Width: 0.5}
+		double = Border{Style: BORDER_DOUBLE, Width: 0.5}
+	)
+	table.SetInnerBorder(Border{Color: "0,255,0"})
+
+	cases := []struct {
+		a, b   Border
+		outer  bool
+		expect Border
+	}{
+		{Border{}, Border{}, true, table.outerBorder},
+		{Border{}, Border{}, false, table.innerBorder},
+		{none, Border{}, false, none},
+		{Border{}, thin, false, thin},
+		{none, none, false, none},
+		{none, thin, false, thin},
+		{thin, thick, false, thick},
+		{dashed, thin, false, thin},
+		{double, thin, false, double},
+		{thin, Border{Style: BORDER_SOLID, Width: 0.5, Color: "255,0,0"}, false, thin},
+	}
+	for i, c := range cases {
+		if got := table.collapseBorder(c.a, c.b, c.outer); got != c.expect {
+			t.Errorf("case %v: expect %+v, got %+v", i, c.expect, got)
+		}
+	}
+}
+
+func TestTableBorderInvalid(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Errorf("expect panic for invalid border style")
+		}
+	}()
+
+	table := NewTable(1, 1, 100, 20, core.CreateReport())
+	table.NewCell().SetBorder(Border{Style: 9})
+}
+
+func TestTableStyles(t *testing.T) {
+	var (
+		font  = core.Font{Family: TABLE_MD, Size: 10}
+		data  = [][]string{{"name", "delta"}, {"a", "10"}, {"b", "-5"}, {"c", "(3)"}, {"d", "n/a"}, {"total", "-1"}}
+		cells [][]*TextCell
+	)
+
+	r := TableReport(func(report *core.Report) {
+		table := NewTable(2, len(data), 200, 20, report)
+		table.SetHeaderRows(1)
+		table.SetFooterRows(1)
+		table.SetHeaderStyle(CellStyle{BackColor: "0,0,128", FontColor: "255,255,255", FontStyle: "B"})
+		table.SetStripeStyles(CellStyle{BackColor: "255,255,255"}, CellStyle{BackColor: "240,240,240"})
+		table.AddConditionalFormat(NegativeRedBold(), 1)
+		table.AddConditionalFormat(ColorScale{MinColor: "0,0,0", MaxColor: "200,100,0"}, 1)
+
+		for _, row := range data {
+			var texts []*TextCell
+			for _, s := range row {
+				text := NewTextCell(100, 20, 0, report).SetFont(font).SetContent(s)
+				table.NewCell().SetElement(text)
+				texts = append(texts, text)
+			}
+			cells = append(cells, texts)
+		}
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	expect := []struct {
+		row, col        int
+		back, color, fs string
+	}{
+		{0, 0, "0,0,128", "255,255,255", "B"},
+		{1, 0, "255,255,255", "", ""},
+		{2, 0, "240,240,240", "", ""},
+		{3, 0, "255,255,255", "", ""},
+		{1, 1, "200,100,0", "", ""},
+		{2, 1, "0,0,0", "255,0,0", "B"},
+		{3, 1, "27,13,0", "255,0,0", "B"},
+		{4, 1, "240,240,240", "", ""},
+		{5, 1, "", "", ""},
+	}
+	for _, e := range expect {
+		cell := cells[e.row][e.col]
+		if cell.backColor != e.back || cell.fontColor != e.color || cell.font.Style != e.fs {
+			t.Errorf("cell (%v, %v): expect %q %q %q, got %q %q %q", e.row, e.col, e.back, e.color, e.fs,
+				cell.backColor, cell.fontColor, cell.font.Style)
+		}
+	}
+
+	var bold bool
+	for _, cell := range *r.GetAtomicCells() {
+		bold = bold || cell == "F|"+TABLE_MD+"|B|10"
+	}
+	if !bold {
+		t.Errorf("expect bold header font")
+	}
+}
+
+func TestTableStyleNumbers(t *testing.T) {
+	cases := map[string]float64{"12": 12, "-1,234.5": -1234.5, "$3": 3, "-¥2": -2, "(7)": -7, " 15% ": 15}
+	for s, expect := range cases {
+		if value, ok := parseNumber(s); !ok || value != expect {
+			t.Errorf("%q: expect %v, got %v %v", s, expect, value, ok)
+		}
+	}
+	for _, s := range []string{"", "n/a", "NaN", "1.2.3"} {
+		if _, ok := parseNumber(s); ok {
+			t.Errorf("%q: expect not a number", s)
+		}
+	}
+
+	scale := ColorScale{MinColor: "255,0,0", MidColor: "255,255,0", MaxColor: "0,255,0"}
+	for value, expect := range map[float64]string{0: "255,0,0", 25: "255,128,0", 50: "255,255,0", 100: "0,255,0"} {
+		if style, _ := scale.Format(value, 0, 100); style.BackColor != expect {
+			t.Errorf("%v: expect %v, got %v", value, expect, style.BackColor)
+		}
+	}
+}
+
+func TestTableStyleInvalid(t *testing.T) {
+	table := NewTable(1, 1, 100, 20, core.CreateReport())
+	for _, fn := range []func(){
+		func() { table.SetStripeStyles(CellStyle{FontStyle: "X"}) },
+		func() { table.AddConditionalFormat(Threshold{Op: "=>"}) },
+		func() { table.AddConditionalFormat(NegativeRedBold(), 1) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+// 分页控制: 在页面的底部 space 的位置开始写入表格, 返回每一页的文字
+func keepTable(space float64, fn func(table *Table, text func(string) *TextCell)) map[string]int {
+	var font = core.Font{Family: TABLE_MD, Size: 10}
+
+	r := TableReport(func(report *core.Report) {
+		x, _ := report.GetXY()
+		_, pageEndY := report.GetPageEndXY()
+		report.SetXY(x, pageEndY-space)
+
+		text := func(s string) *TextCell {
+			return NewTextCell(200, 14, 0, report).SetFont(font).SetContent(s)
+		}
+		fn(NewTable(1, 3, 200, 18, report), text)
+	})
+	r.GetBytesPdf()
+
+	pages := make(map[string]int)
+	for _, text := range componentTexts(*r.GetAtomicCells()) {
+		pages[text.content] = text.page
+	}
+	return pages
+}
+
+// 每一页的行数
+func keepLines(pages map[string]int, lines int) (counts [2]int) {
+	for i := 0; i < lines; i++ {
+		counts[pages["l"+strconv.Itoa(i)]]++
+	}
+	return counts
+}
+
+func keepContent(lines int) string {
+	var s []string
+	for i := 0; i < lines; i++ {
+		s = append(s, "l"+strconv.Itoa(i))
+	}
+	return strings.Join(s, "\n")
+}
+
+func TestTableKeepTogether(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		pages := keepTable(60, func(table *Table, text func(string) *TextCell) {
+			if keep {
+				table.SetKeepTogether(1)
+			}
+			table.NewCell().SetElement(text("head"))
+			table.NewCell().SetElement(text(keepContent(10)))
+			table.NewCell().SetElement(text("tail"))
+			table.GenerateAtomicCell()
+		})
+
+		expect := [2]int{3, 7}
+		if keep {
+			expect = [2]int{0, 10}
+		}
+		if counts := keepLines(pages, 10); counts != expect || pages["head"] != 0 {
+			t.Errorf("keep %v: expect %v, got %v head on page %v", keep, expect, counts, pages["head"])
+		}
+	}
+}
+
+func TestTableMinSplitLines(t *testing.T) {
+	cases := []struct {
+		space  float64
+		expect [2]int
+	}{
+		{20, [2]int{0, 10}},  // 孤行: 只能写入 1 行
+		{130, [2]int{7, 3}},  // 寡行: 只剩余 1 行
+		{60, [2]int{4, 6}},   // 满足
+		{146, [2]int{10, 0}}, // 不需要拆分
+	}
+	for _, c := range cases {
+		pages := keepTable(c.space, func(table *Table, text func(string) *TextCell) {
+			table.SetMinSplitLines(2, 3)
+			table.NewCell().SetElement(text(keepContent(10)))
+			table.NewCell().SetElement(text("a"))
+			table.NewCell().SetElement(text("b"))
+			table.GenerateAtomicCell()
+		})
+		if counts := keepLines(pages, 10); counts != c.expect {
+			t.Errorf("space %v: expect %v, got %v", c.space, c.expect, counts)
+		}
+	}
+}
+
+func TestTableKeepWithNext(t *testing.T) {
+	pages := keepTable(58, func(table *Table, text func(string) *TextCell) {
+		table.SetKeepWithNext(1)
+		table.SetKeepTogether(2)
+		table.NewCell().SetElement(text("first"))
+		table.NewCell().SetElement(text("caption"))
+		table.NewCell().SetElement(text(keepContent(10)))
+		table.GenerateAtomicCell()
+	})
+	if pages["first"] != 0 || pages["caption"] != 1 || keepLines(pages, 10) != [2]int{0, 10} {
+		t.Errorf("expect caption with the next row, got %v", pages)
+	}
+
+	// 所有的行串联在一起, 整体移到下一页
+	pages = keepTable(58, func(table *Table, text func(string) *TextCell) {
+		table.SetKeepWithNext(0, 1)
+		table.SetKeepTogether(2)
+		table.NewCell().SetElement(text("first"))
+		table.NewCell().SetElement(text("caption"))
+		table.NewCell().SetElement(text(keepContent(10)))
+		table.GenerateAtomicCell()
+	})
+	if pages["first"] != 1 || pages["caption"] != 1 {
+		t.Errorf("expect all rows on the next page, got %v", pages)
+	}
+}
+
+func TestTableKeepInvalid(t *testing.T) {
+	table := NewTable(1, 2, 100, 20, core.CreateReport())
+	for _, fn := range []func(){
+		func() { table.SetKeepTogether(2) },
+		func() { table.SetKeepWithNext(-1) },
+		func() { table.SetMinSplitLines(-1, 0) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic")
+				}
+			}()
+			fn()
+		}()
+	}
+}
+
+func TestTableColumnPaging(t *testing.T) {
+	var (
+		font         = core.Font{Family: TABLE_MD, Size: 10}
+		rows, cols   = 20, 16
+		contentWidth float64
+		name         = func(row, col int) string { return fmt.Sprintf("r%dc%d", row, col) }
+		widths       []string
+	)
+	for i := 0; i < cols; i++ {
+		widths = append(widths, "60")
+	}
+
+	r := TableReport(func(report *core.Report) {
+		contentWidth, _ = report.GetContentWidthAndHeight()
+		table := NewTable(cols, rows, 300, 18, report)
+		table.SetColumnPaging(0, 1)
+		table.SetColumnWidths(widths...)
+		table.SetColumnLabel("columns %d-%d", font)
+		table.SetHeaderRows(1)
+
+		for i := 0; i < rows; i++ {
+			for j := 0; j < cols; j++ {
+				content := name(i, j)
+				if i == 3 && j == cols-1 {
+					content = "tall\ncell\ncontent"
+				}
+				if i == 2 && j == 7 {
+					table.NewCellByRange(2, 1).SetElement(NewTextCell(120, 14, 0, report).SetFont(font).SetContent(content))
+					j++
+					continue
+				}
+				table.NewCell().SetElement(NewTextCell(60, 14, 0, report).SetFont(font).SetContent(content))
+			}
+		}
+		table.GenerateAtomicCell()
+	})
+	r.GetBytesPdf()
+
+	var (
+		texts  = map[string][]componentText{}
+		labels []string
+	)
+	for _, text := range componentTexts(*r.GetAtomicCells()) {
+		texts[text.content] = append(texts[text.content], text)
+		if strings.HasPrefix(text.content, "columns ") {
+			labels = append(labels, text.content)
+		}
+	}
+
+	// 每一部分: 2 个关键列, 其余的列按照剩余的宽度分配, 跨列的单元格(7, 8)不拆分
+	var (
+		per    = int((contentWidth - 120) / 60)
+		expect []string
+	)
+	for start := 2; start < cols; {
+		end := start + per
+		if end > cols {
+			end = cols
+		}
+		if start <= 7 && end == 8 {
+			end = 7
+		}
+		expect = append(expect, fmt.Sprintf("columns %d-%d", start+1, end))
+		start = end
+	}
+	if strings.Join(labels, ",") != strings.Join(expect, ",") {
+		t.Fatalf("expect labels %v, got %v", expect, labels)
+	}
+
+	for i := 0; i < rows; i++ {
+		keys := texts[name(i, 0)]
+		if len(keys) != len(expect) {
+			t.Fatalf("row %v: expect key column in %v strips, got %v", i, len(expect), len(keys))
+		}
+		for j := 2; j < cols; j++ {
+			cell := texts[name(i, j)]
+			if i == 2 && (j == 7 || j == 8) || i == 3 && j == cols-1 {
+				continue
+			}
+			if len(cell) != 1 {
+				t.Fatalf("%v: expect once, got %v", name(i, j), cell)
+			}
+			// 同一行在每一部分的位置相同
+			strip := keys[0]
+			for _, key := range keys {
+				if key.page == cell[0].page {
+					strip = key
+				}
+			}
+			if strip.page != cell[0].page || math.Abs(strip.y-cell[0].y) > 0.01 {
+				t.Errorf("%v: expect aligned with key column %+v, got %+v", name(i, j), strip, cell[0])
+			}
+		}
+	}
+}
+
+func TestTableColumnPagingInvalid(t *testing.T) {
+	for _, fn := range []func(table *Table){
+		func(table *Table) { table.SetColumnPaging(2) },
+		func(table *Table) {
+			table.SetColumnPaging(0)
+			table.SetColumnWidths("60", "50%")
+		},
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("expect panic")
+				}
+			}()
+			fn(NewTable(2, 1, 100, 20, core.CreateReport()))
+		}()
+	}
+}
@@ -35,6 +35,9 @@ func addCommaSub(s string) string {
 	return res
 }
 
+// 读取 TSV 文件, 列数少于 colno 的行被忽略
+//
+// Deprecated: 使用 gopdf.TableBuilder 的 FromCSV(reader, '\t') 直接构建表格
 func ReadTextFile(filename string, colno int) []interface{} {
 	res, _ := ioutil.ReadFile(filename)
 	lines := strings.Split(string(res), "\n")